@@ -0,0 +1,30 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+type contextKey int
+
+const httpClientKey contextKey = iota
+
+// NewContext returns a copy of ctx carrying the given HTTP client. Checks executed with the
+// returned context use the client instead of creating a new one, e.g. to reach a fake cluster in tests.
+func NewContext(ctx context.Context, c *http.Client) context.Context {
+	return context.WithValue(ctx, httpClientKey, c)
+}
+
+// FromContext returns the HTTP client stored in ctx, if any.
+func FromContext(ctx context.Context) (*http.Client, bool) {
+	c, ok := ctx.Value(httpClientKey).(*http.Client)
+	return c, ok && c != nil
+}
+
+// NewClientContext returns the HTTP client stored in ctx, or a new client ready to handle DC/OS security.
+func NewClientContext(ctx context.Context, iamConfig, caCert string) (*http.Client, error) {
+	if c, ok := FromContext(ctx); ok {
+		return c, nil
+	}
+	return NewClient(iamConfig, caCert)
+}
@@ -1,9 +1,22 @@
 package client
 
 import (
+	"net"
 	"net/http"
+	"time"
 
 	"github.com/dcos/dcos-go/dcos/http/transport"
+	"github.com/pkg/errors"
+)
+
+// Connection limits and timeouts of the pooled HTTP client.
+const (
+	dialTimeout           = 5 * time.Second
+	keepAlive             = 30 * time.Second
+	tlsHandshakeTimeout   = 5 * time.Second
+	responseHeaderTimeout = 30 * time.Second
+	idleConnTimeout       = 90 * time.Second
+	maxIdleConnsPerHost   = 4
 )
 
 // NewClient returns a new http client ready to handle DC/OS security.
@@ -30,3 +43,46 @@ func NewClient(iamConfig, caCert string) (*http.Client, error) {
 		Transport: tr,
 	}, nil
 }
+
+// NewPooledClient returns a new http client ready to handle DC/OS security, meant to be shared by
+// all requests of a run. The TLS and IAM setup is the one of NewClient, the transport additionally
+// keeps idle connections open for reuse and limits every connection by dial, TLS handshake and
+// response header timeouts.
+func NewPooledClient(iamConfig, caCert string) (*http.Client, error) {
+	var transportOptions []transport.OptionTransportFunc
+	if caCert != "" {
+		transportOptions = append(transportOptions, transport.OptionCaCertificatePath(caCert))
+	}
+
+	// without an IAM config the DC/OS transport is an *http.Transport with the TLS config set.
+	rt, err := transport.NewTransport(transportOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	tr, ok := rt.(*http.Transport)
+	if !ok {
+		return nil, errors.Errorf("unexpected DC/OS transport %T", rt)
+	}
+
+	tr.Proxy = http.ProxyFromEnvironment
+	tr.DialContext = (&net.Dialer{
+		Timeout:   dialTimeout,
+		KeepAlive: keepAlive,
+	}).DialContext
+	tr.TLSHandshakeTimeout = tlsHandshakeTimeout
+	tr.ResponseHeaderTimeout = responseHeaderTimeout
+	tr.IdleConnTimeout = idleConnTimeout
+	tr.MaxIdleConnsPerHost = maxIdleConnsPerHost
+
+	// the IAM round tripper is added the same way transport.NewTransport adds it.
+	if iamConfig != "" {
+		withIAM, err := transport.NewRoundTripper(tr, transport.OptionReadIAMConfig(iamConfig))
+		if err != nil {
+			return nil, err
+		}
+		return &http.Client{Transport: withIAM}, nil
+	}
+
+	return &http.Client{Transport: tr}, nil
+}
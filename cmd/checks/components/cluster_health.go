@@ -0,0 +1,235 @@
+package components
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/dcos/dcos-checks/common"
+	"github.com/dcos/dcos-checks/constants"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// clusterNodesResponse is a response of dcos-diagnostics /system/health/v1/nodes and
+// /system/health/v1/units/<unit>/nodes endpoints available on masters.
+type clusterNodesResponse struct {
+	Nodes []struct {
+		HostIP string `json:"host_ip"`
+		Health int    `json:"health"`
+		Role   string `json:"role"`
+		Output string `json:"output"`
+	} `json:"nodes"`
+}
+
+// unhealthyUnit is a unit which is unhealthy on some nodes of the cluster.
+type unhealthyUnit struct {
+	ID    string
+	Name  string
+	Help  string
+	Nodes []string
+
+	// Status is the check status of the unit, a failure or a warning.
+	Status int
+}
+
+// clusterHealth is the health of the cluster units aggregated by dcos-diagnostics.
+type clusterHealth struct {
+	// Roles maps the node IPs to their roles.
+	Roles map[string]string
+
+	// Units are the unhealthy units sorted by ID.
+	Units []unhealthyUnit
+
+	// Items contains a result for every unhealthy unit on every node.
+	Items []common.ItemResult
+}
+
+// getClusterHealth walks the cluster health endpoints of dcos-diagnostics at base. Units
+// reported unhealthy by /units are looked up with /units/<unit>/nodes.
+func getClusterHealth(ctx context.Context, httpClient *http.Client, base *url.URL, filter *unitFilter) (*clusterHealth, error) {
+	var nodes clusterNodesResponse
+	if err := getJSON(ctx, httpClient, healthEndpoint(base, "nodes"), &nodes); err != nil {
+		return nil, err
+	}
+
+	health := &clusterHealth{Roles: make(map[string]string, len(nodes.Nodes))}
+	for _, node := range nodes.Nodes {
+		health.Roles[node.HostIP] = node.Role
+	}
+
+	var units diagnosticsResponse
+	if err := getJSON(ctx, httpClient, healthEndpoint(base, "units"), &units); err != nil {
+		return nil, err
+	}
+
+	for _, unit := range units.Units {
+		if unit.Health == constants.StatusOK || filter.skip(unit.ID) {
+			continue
+		}
+
+		var unitNodes clusterNodesResponse
+		if err := getJSON(ctx, httpClient, healthEndpoint(base, "units", unit.ID, "nodes"), &unitNodes); err != nil {
+			return nil, err
+		}
+
+		u := unhealthyUnit{ID: unit.ID, Name: unit.Name, Help: unit.Help, Status: filter.status(unit.ID, unit.Health)}
+		for _, node := range unitNodes.Nodes {
+			if node.Health == constants.StatusOK {
+				continue
+			}
+
+			u.Nodes = append(u.Nodes, node.HostIP)
+			health.Items = append(health.Items, common.ItemResult{
+				Name:   fmt.Sprintf("%s %s", node.HostIP, unit.ID),
+				Status: filter.status(unit.ID, node.Health),
+				Output: unitMessage(unit.Name, node.Health, node.Output, unit.Help),
+			})
+		}
+
+		if len(u.Nodes) > 0 {
+			sort.Strings(u.Nodes)
+			health.Units = append(health.Units, u)
+		}
+	}
+
+	sort.Slice(health.Units, func(i, j int) bool {
+		return health.Units[i].ID < health.Units[j].ID
+	})
+	return health, nil
+}
+
+// byUnit maps the unhealthy unit IDs to the nodes they are unhealthy on.
+func (h *clusterHealth) byUnit() map[string][]string {
+	units := make(map[string][]string, len(h.Units))
+	for _, unit := range h.Units {
+		units[unit.ID] = unit.Nodes
+	}
+	return units
+}
+
+// byNode maps the node IPs to the unhealthy units on the node, sorted by ID.
+func (h *clusterHealth) byNode() map[string][]string {
+	nodes := make(map[string][]string)
+	for _, unit := range h.Units {
+		for _, node := range unit.Nodes {
+			nodes[node] = append(nodes[node], unit.ID)
+		}
+	}
+	return nodes
+}
+
+// statusCounts returns the numbers of nodes whose worst unhealthy unit is mapped to a warning
+// and to a failure.
+func (h *clusterHealth) statusCounts() (warning, failure int) {
+	worst := make(map[string]int)
+	for _, unit := range h.Units {
+		for _, node := range unit.Nodes {
+			if unit.Status > worst[node] {
+				worst[node] = unit.Status
+			}
+		}
+	}
+
+	for _, status := range worst {
+		if status == constants.StatusWarning {
+			warning++
+		} else {
+			failure++
+		}
+	}
+	return warning, failure
+}
+
+// result returns a check result reporting the unhealthy units grouped by unit and by node.
+func (h *clusterHealth) result() common.CheckResult {
+	byNode := h.byNode()
+	warning, failure := h.statusCounts()
+	result := common.CheckResult{
+		Status: constants.StatusOK,
+		Items:  h.Items,
+		Data: map[string]interface{}{
+			"nodes":   len(h.Roles),
+			"by_unit": h.byUnit(),
+			"by_node": byNode,
+		},
+		PerfData: statusPerfData("nodes", warning, failure, len(h.Roles)),
+	}
+
+	if len(h.Units) == 0 {
+		result.Summary = fmt.Sprintf("all components are healthy on %d nodes", len(h.Roles))
+		return result
+	}
+
+	result.Status = constants.StatusWarning
+	for _, unit := range h.Units {
+		if unit.Status > result.Status {
+			result.Status = unit.Status
+		}
+	}
+	result.Summary = fmt.Sprintf("%d components are unhealthy on %d of %d nodes", len(h.Units), len(byNode), len(h.Roles))
+
+	details := []string{"by unit:"}
+	for _, unit := range h.Units {
+		details = append(details, fmt.Sprintf("  %s (%s): %s", unit.ID, unit.Name, strings.Join(unit.Nodes, ", ")))
+
+		hint := unit.Help
+		if hint == "" {
+			hint = fmt.Sprintf("inspect the unit logs with journalctl -u %s and restart it", unit.ID)
+		}
+		result.Remediation = append(result.Remediation, common.Remediation{
+			Hint:    fmt.Sprintf("%s on %s: %s", unit.Name, strings.Join(unit.Nodes, ", "), hint),
+			Command: fmt.Sprintf("systemctl restart %s", unit.ID),
+		})
+	}
+
+	nodes := make([]string, 0, len(byNode))
+	for node := range byNode {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	details = append(details, "by node:")
+	for _, node := range nodes {
+		details = append(details, fmt.Sprintf("  %s (%s): %s", node, h.Roles[node], strings.Join(byNode[node], ", ")))
+	}
+
+	result.Details = strings.Join(details, "\n")
+	return result
+}
+
+// healthEndpoint returns a URL of a cluster health endpoint relative to the health URL base.
+func healthEndpoint(base *url.URL, elem ...string) *url.URL {
+	u := *base
+	u.Path = path.Join(append([]string{base.Path}, elem...)...)
+	return &u
+}
+
+// getJSON makes a GET request and decodes the JSON response into v.
+func getJSON(ctx context.Context, httpClient *http.Client, u *url.URL, v interface{}) error {
+	logrus.Debugf("GET %s", u)
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return errors.Wrap(err, "unable to create a new HTTP request")
+	}
+
+	resp, err := httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return errors.Wrapf(err, "unable to execute GET %s", u)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("GET %s returned %s", u, resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return errors.Wrapf(err, "unable to unmarshal response of %s", u)
+	}
+	return nil
+}
@@ -22,87 +22,219 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/dcos/dcos-checks/client"
 	"github.com/dcos/dcos-checks/common"
 	"github.com/dcos/dcos-checks/constants"
+	"github.com/dcos/dcos-go/dcos"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
-	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
+// dcosDiagnosticsMasterPort is a port dcos-diagnostics listens on master nodes.
+const dcosDiagnosticsMasterPort = 1050
+
 // componentCheck validates that all systemd units are healthy by making a GET request
 // to dcos-diagnostics endpoint /system/health/v1 on the localhost.
 // In open DC/OS 3dt listens port 1050 on master nodes. On agent nodes, 3dt uses socket activation to bind on
 // unix socket. Adminrouter is used to make a reverse proxy.
 type componentCheck struct {
 	Name string
-}
 
-var (
-	healthURLPrefix   string
-	scheme            string
-	port              int
-	excludeComponents []string
-)
+	HealthURL string
+	Scheme    string
+	Port      int
+
+	// Include and Exclude are unit ID patterns selecting the checked units. All units are
+	// checked if Include is empty.
+	Include []string
+	Exclude []string
+
+	// Warning are unit ID patterns of non-critical units, reported as warnings if unhealthy.
+	Warning []string
 
-// componentsCmd represents the systemd health check
-var componentsCmd = &cobra.Command{
-	Use:   "components",
-	Short: "Check DC/OS components",
-	Long: `Check DC/OS components health by making a GET request to dcos-3dt service
+	// ClusterWide checks the units of every node using the cluster health aggregated on masters.
+	ClusterWide bool
+}
+
+func init() {
+	common.RegisterCheck(common.CheckSpec{
+		Name:        "components",
+		Description: "Check DC/OS components",
+		Long: `Check DC/OS components health by making a GET request to dcos-3dt service
 and validating the health field:
 
 /system/health/v1 is the local endpoint. The response structure is the following
 {
   "units": ["unit1", ...]
 }
+
+The checked units are selected by --include and --exclude unit ID patterns. A pattern is a glob,
+e.g. dcos-*-exporter*, or a regular expression enclosed in slashes, e.g. /^dcos-.*-exporter/.
+Unhealthy units matching --warning patterns are reported as warnings instead of failures.
+
+With --cluster-wide the check is executed on a master and walks the cluster health aggregated
+by dcos-diagnostics: /system/health/v1/nodes, /system/health/v1/units and
+/system/health/v1/units/<unit>/nodes. The unhealthy units are reported grouped by unit and by node.
 `,
-	Run: func(cmd *cobra.Command, args []string) {
-		common.RunCheck(context.TODO(),
-			&componentCheck{"DC/OS components health check"})
-	},
+		Roles:    []string{dcos.RoleMaster, dcos.RoleAgent, dcos.RoleAgentPublic},
+		Tags:     []string{"node", "http"},
+		Requires: []string{"ip"},
+		Timeout:  10 * time.Second,
+		Flags:    addFlags,
+		New:      newCheckFromFlags,
+	})
+}
+
+// addFlags adds the check parameters to the flag set.
+func addFlags(flags *pflag.FlagSet) {
+	flags.StringP("health-url", "u", "/system/health/v1", "Set dcos-diagnostics health url")
+	flags.StringP("scheme", "s", "http", "Set dcos-diagnostics health url scheme")
+	flags.IntP("port", "p", 0, "Set TCP port (default adminrouter port on agents, 1050 otherwise)")
+	flags.StringSliceP("include", "i", nil, "Check only the components matching the patterns")
+	flags.StringSliceP("exclude", "e", nil, "Exclude components matching the patterns from health check")
+	flags.StringSliceP("warning", "w", nil, "Report unhealthy components matching the patterns as warnings")
+	flags.Bool("cluster-wide", false, "Check the components of every node using the cluster health aggregated on masters")
+}
+
+// newCheckFromFlags returns a components check configured with the given flags.
+func newCheckFromFlags(flags *pflag.FlagSet, args []string) (common.DCOSChecker, error) {
+	check := newComponentCheck("DC/OS components health check")
+
+	var err error
+	if check.HealthURL, err = flags.GetString("health-url"); err != nil {
+		return nil, err
+	}
+
+	if check.Scheme, err = flags.GetString("scheme"); err != nil {
+		return nil, err
+	}
+
+	if check.Port, err = flags.GetInt("port"); err != nil {
+		return nil, err
+	}
+
+	if check.Include, err = flags.GetStringSlice("include"); err != nil {
+		return nil, err
+	}
+
+	if check.Exclude, err = flags.GetStringSlice("exclude"); err != nil {
+		return nil, err
+	}
+
+	if check.Warning, err = flags.GetStringSlice("warning"); err != nil {
+		return nil, err
+	}
+
+	// validate the unit patterns before the check is executed.
+	if _, err = newUnitFilter(check.Include, check.Exclude, check.Warning); err != nil {
+		return nil, err
+	}
+
+	if check.ClusterWide, err = flags.GetBool("cluster-wide"); err != nil {
+		return nil, err
+	}
+
+	return check, nil
 }
 
-// Register adds this command to the root command
-func Register(root *cobra.Command) {
-	root.AddCommand(componentsCmd)
-	componentsCmd.Flags().StringVarP(&healthURLPrefix, "health-url", "u", "/system/health/v1", "Set dcos-diagnostics health url")
-	componentsCmd.Flags().StringVarP(&scheme, "scheme", "s", "http", "Set dcos-diagnostics health url scheme")
-	componentsCmd.Flags().IntVarP(&port, "port", "p", 1050, "Set TCP port")
-	componentsCmd.Flags().StringSliceVarP(&excludeComponents, "exclude", "e", nil, "Exclude components from health check")
+// newComponentCheck returns an initialized instance of *componentCheck.
+func newComponentCheck(name string) *componentCheck {
+	return &componentCheck{Name: name}
 }
 
 // Run invokes a systemd check and return error output, exit code and error.
 func (c *componentCheck) Run(ctx context.Context, cfg *common.CLIConfigFlags) (string, int, error) {
-	httpClient, err := client.NewClient(cfg.IAMConfig, cfg.CACert)
+	return c.RunDetailed(ctx, cfg).Tuple()
+}
+
+// RunDetailed invokes a systemd check and returns a result with an item for each unit.
+func (c *componentCheck) RunDetailed(ctx context.Context, cfg *common.CLIConfigFlags) common.CheckResult {
+	filter, err := newUnitFilter(c.Include, c.Exclude, c.Warning)
 	if err != nil {
-		return "", constants.StatusUnknown, errors.Wrap(err, "unable to create HTTP client")
+		return unknownResult(err)
 	}
 
-	url, err := c.getHealthURL(httpClient, healthURLPrefix, scheme, port, cfg)
+	httpClient, err := client.NewClientContext(ctx, cfg.IAMConfig, cfg.CACert)
 	if err != nil {
-		return "", constants.StatusUnknown, err
+		return unknownResult(errors.Wrap(err, "unable to create HTTP client"))
+	}
+
+	url, err := c.healthURL(ctx, httpClient, cfg)
+	if err != nil {
+		return unknownResult(err)
+	}
+
+	if c.ClusterWide {
+		return c.runClusterWide(ctx, httpClient, url, filter, cfg)
 	}
+
 	logrus.Debugf("GET %s", url)
 	req, err := http.NewRequest("GET", url.String(), nil)
 	if err != nil {
-		return "", constants.StatusUnknown, errors.Wrap(err, "unable to create a new HTTP request")
+		return unknownResult(errors.Wrap(err, "unable to create a new HTTP request"))
 	}
 
-	resp, err := httpClient.Do(req)
+	resp, err := httpClient.Do(req.WithContext(ctx))
 	if err != nil {
-		return "", constants.StatusUnknown, errors.Wrapf(err, "unable to execute GET %s", healthURLPrefix)
+		return unknownResult(errors.Wrapf(err, "unable to execute GET %s", c.HealthURL))
 	}
 	defer resp.Body.Close()
 
 	var dr diagnosticsResponse
 	if err := json.NewDecoder(resp.Body).Decode(&dr); err != nil {
-		return "", constants.StatusUnknown, errors.Wrap(err, "unable to unmarshal diagnostics response")
+		return unknownResult(errors.Wrap(err, "unable to unmarshal diagnostics response"))
+	}
+
+	errorList, retCode := dr.checkHealth(filter)
+	result := common.NewCheckResult(strings.Join(errorList, "\n"), retCode, nil)
+	result.Items = dr.items(filter)
+	warning, failure := dr.statusCounts(filter)
+	result.Data = map[string]interface{}{
+		"units":           len(dr.Units),
+		"unhealthy_units": len(errorList),
+		"warning_units":   warning,
+		"failed_units":    failure,
+	}
+	result.PerfData = statusPerfData("units", warning, failure, len(dr.Units))
+	result.Remediation = dr.remediation(filter)
+	return result
+}
+
+// runClusterWide returns a result reporting the unhealthy units of every node of the cluster.
+func (c *componentCheck) runClusterWide(ctx context.Context, httpClient *http.Client, base *url.URL,
+	filter *unitFilter, cfg *common.CLIConfigFlags) common.CheckResult {
+	if cfg.Role != dcos.RoleMaster {
+		return unknownResult(errors.Errorf("cluster health is only available on masters, got role %s", cfg.Role))
+	}
+
+	health, err := getClusterHealth(ctx, httpClient, base, filter)
+	if err != nil {
+		return unknownResult(err)
+	}
+	return health.result()
+}
+
+// unknownResult returns a result of a check which could not be completed.
+func unknownResult(err error) common.CheckResult {
+	return common.CheckResult{Status: constants.StatusUnknown, Err: err}
+}
+
+// Explain returns the dcos-diagnostics health request made by the check.
+func (c *componentCheck) Explain(ctx context.Context, cfg *common.CLIConfigFlags) ([]common.Action, error) {
+	host, actions := common.ExplainNodeIP(cfg)
+	url := c.healthURLOn(host, cfg)
+	if !c.ClusterWide {
+		return append(actions, common.HTTPAction(cfg, "GET", url, "dcos-diagnostics units health")), nil
 	}
 
-	errorList, retCode := dr.checkHealth(excludeComponents)
-	return strings.Join(errorList, "\n"), retCode, nil
+	return append(actions,
+		common.HTTPAction(cfg, "GET", healthEndpoint(url, "nodes"), "cluster nodes health"),
+		common.HTTPAction(cfg, "GET", healthEndpoint(url, "units"), "cluster units health"),
+		common.HTTPAction(cfg, "GET", healthEndpoint(url, "units", "<unit>", "nodes"), "nodes health of every unhealthy unit"),
+	), nil
 }
 
 // ID returns a unique check identifier.
@@ -110,15 +242,51 @@ func (c *componentCheck) ID() string {
 	return c.Name
 }
 
-func (c *componentCheck) getHealthURL(httpClient *http.Client, path, scheme string, port int, cfg *common.CLIConfigFlags) (*url.URL, error) {
-	ip, err := cfg.IP(httpClient)
+// Tags returns the cluster tag if the check walks the cluster health aggregated on masters,
+// so it is not executed against every node with --cluster.
+func (c *componentCheck) Tags() []string {
+	if c.ClusterWide {
+		return []string{"cluster"}
+	}
+	return nil
+}
+
+// defaultPort returns a port dcos-diagnostics health endpoint is available on. On agent nodes
+// dcos-diagnostics binds on a unix socket and is reachable via adminrouter only. If the role is
+// unknown, the master port is used.
+func defaultPort(role, scheme string) int {
+	if role != dcos.RoleAgent && role != dcos.RoleAgentPublic {
+		return dcosDiagnosticsMasterPort
+	}
+
+	if scheme == constants.HTTPSScheme {
+		return constants.AdminrouterAgentHTTPSPort
+	}
+	return constants.AdminrouterAgentHTTPPort
+}
+
+// healthURL returns the dcos-diagnostics health URL of the node. The port defaults to the role port.
+func (c *componentCheck) healthURL(ctx context.Context, httpClient *http.Client, cfg *common.CLIConfigFlags) (*url.URL, error) {
+	ip, err := common.NodeIP(ctx, cfg, httpClient)
 	if err != nil {
 		return nil, err
 	}
+	return c.healthURLOn(ip.String(), cfg), nil
+}
 
+// healthURLOn returns the dcos-diagnostics health URL of the node with the given host.
+func (c *componentCheck) healthURLOn(host string, cfg *common.CLIConfigFlags) *url.URL {
+	port := c.Port
+	if port == 0 {
+		port = defaultPort(cfg.Role, c.Scheme)
+	}
+	return getHealthURL(host, c.HealthURL, c.Scheme, port)
+}
+
+func getHealthURL(host, path, scheme string, port int) *url.URL {
 	return &url.URL{
 		Scheme: scheme,
-		Host:   net.JoinHostPort(ip.String(), strconv.Itoa(port)),
+		Host:   net.JoinHostPort(host, strconv.Itoa(port)),
 		Path:   path,
-	}, nil
+	}
 }
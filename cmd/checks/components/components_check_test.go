@@ -1,18 +1,23 @@
 package components
 
 import (
+	"context"
 	"encoding/json"
 	"strings"
 	"testing"
 
 	"github.com/dcos/dcos-checks/common"
+	"github.com/dcos/dcos-checks/constants"
+	"github.com/dcos/dcos-checks/fakecluster"
+	"github.com/spf13/pflag"
 )
 
 // TestComponentCheckGetHealthURL validates that parameters Role and ForceTLS
 // return the expected URL based on adminrouter / 3dt configuration.
 func TestComponentCheckGetHealthURL(t *testing.T) {
 	c := &componentCheck{
-		Name: "TEST",
+		Name:      "TEST",
+		HealthURL: "/",
 	}
 
 	for _, item := range []struct {
@@ -58,22 +63,51 @@ func TestComponentCheckGetHealthURL(t *testing.T) {
 			expected: "https://127.0.0.1:61002/",
 		},
 	} {
-		mockCLICfg := &common.CLIConfigFlags{
-			NodeIPStr: "127.0.0.1",
-			Role:      item.role,
-		}
-
-		url, err := c.getHealthURL(nil, "/", item.scheme, item.port, mockCLICfg)
-		if err != nil {
-			t.Fatalf("Error running getHealthURL: %s", err)
-		}
-
+		c.Scheme = item.scheme
+		c.Port = item.port
+		url := c.healthURLOn("127.0.0.1", &common.CLIConfigFlags{Role: item.role})
 		if url.String() != item.expected {
 			t.Fatalf("Expect %s. Got %s", item.expected, url.String())
 		}
 	}
 }
 
+func TestComponentCheckExplain(t *testing.T) {
+	c := newComponentCheck("TEST")
+	c.HealthURL = "/system/health/v1"
+	c.Scheme = "http"
+
+	cfg := &common.CLIConfigFlags{
+		NodeIPStr: "10.0.0.1",
+		Role:      "agent",
+	}
+
+	actions, err := c.Explain(context.TODO(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "GET http://10.0.0.1:61001/system/health/v1 (auth: none) - dcos-diagnostics units health"
+	if len(actions) != 1 || actions[0].String() != expected {
+		t.Fatalf("expect action %q. Got %v", expected, actions)
+	}
+
+	cfg = &common.CLIConfigFlags{
+		DetectIP: "/opt/mesosphere/bin/detect_ip",
+		Role:     "agent",
+	}
+
+	actions, err = c.Explain(context.TODO(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = "GET http://<node ip>:61001/system/health/v1 (auth: none) - dcos-diagnostics units health"
+	if len(actions) != 2 || actions[0].Kind != common.ActionExec || actions[1].String() != expected {
+		t.Fatalf("expect detect_ip and action %q. Got %v", expected, actions)
+	}
+}
+
 func TestDiagnosticsResponse(t *testing.T) {
 	// A sample from the output of system/health/v1 with checks marked as not healthy
 	response := `{"units":[{"id":"dcos-adminrouter.service","health":0,"output":"","description":"exposes a unified control plane proxy for components and services using NGINX","help":"","name":"Admin Router Master"},{"id":"dcos-checks-poststart.service","health":1,"output":"","description":"Run node-poststart checks","help":"","name":"DC/OS Poststart Checks"},{"id":"dcos-checks-poststart.timer","health":1,"output":"","description":"timer for DC/OS Checks service","help":"","name":"DC/OS Checks Timer"},{"id":"dcos-cosmos.service","health":0,"output":"","description":"installs and manages DC/OS packages from DC/OS package repositories, such as the Mesosphere Universe","help":"","name":"DC/OS Package Manager (Cosmos)"},{"id":"dcos-diagnostics.service","health":0,"output":"","description":"aggregates and exposes component health","help":"","name":"DC/OS Diagnostics Master"},{"id":"dcos-diagnostics.socket","health":0,"output":"","description":"socket for DC/OS Diagnostics Agent","help":"","name":"DC/OS Diagnostics Agent Socket"}]}`
@@ -83,14 +117,227 @@ func TestDiagnosticsResponse(t *testing.T) {
 		t.Fatalf("Error decoding")
 	}
 
-	complist := []string{"dcos-checks-poststart.service", "dcos-checks-poststart.timer"}
+	complist, err := newUnitFilter(nil, []string{"dcos-checks-poststart.service", "dcos-checks-poststart.timer"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	all, err := newUnitFilter(nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	_, retCode := dr.checkHealth(complist)
 	if retCode != 0 {
 		t.Fatalf("Component health check failed when it should have passed")
 	}
 
-	_, retCode = dr.checkHealth(nil)
+	_, retCode = dr.checkHealth(all)
 	if retCode == 0 {
 		t.Fatalf("Component health check passed when it should have failed")
 	}
+
+	items := dr.items(complist)
+	if len(items) != 4 {
+		t.Fatalf("expect an item for each unit which is not excluded. Got %+v", items)
+	}
+
+	for _, item := range items {
+		if item.Status != constants.StatusOK {
+			t.Fatalf("expect unit %s to be healthy. Got %+v", item.Name, item)
+		}
+	}
+
+	if remediation := dr.remediation(complist); len(remediation) != 0 {
+		t.Fatalf("expect no remediation of excluded units. Got %v", remediation)
+	}
+
+	remediation := dr.remediation(all)
+	if len(remediation) != 2 || remediation[0].Command != "systemctl restart dcos-checks-poststart.service" {
+		t.Fatalf("expect a restart command for each unhealthy unit. Got %v", remediation)
+	}
+}
+
+func TestDiagnosticsResponseRemediationHelp(t *testing.T) {
+	response := `{"units":[{"id":"dcos-mesos-slave.service","health":1,"help":"Check the agent work dir","name":"Mesos Agent"}]}`
+
+	var dr diagnosticsResponse
+	if err := json.NewDecoder(strings.NewReader(response)).Decode(&dr); err != nil {
+		t.Fatal(err)
+	}
+
+	remediation := dr.remediation(&unitFilter{})
+	expected := "Mesos Agent: Check the agent work dir"
+	if len(remediation) != 1 || remediation[0].Hint != expected {
+		t.Fatalf("expect hint %q. Got %v", expected, remediation)
+	}
+}
+
+func TestDefaultPort(t *testing.T) {
+	for _, item := range []struct {
+		role     string
+		scheme   string
+		expected int
+	}{
+		{role: "master", scheme: "http", expected: 1050},
+		{role: "master", scheme: "https", expected: 1050},
+		{role: "agent", scheme: "http", expected: 61001},
+		{role: "agent_public", scheme: "https", expected: 61002},
+		{role: "", scheme: "http", expected: 1050},
+	} {
+		if port := defaultPort(item.role, item.scheme); port != item.expected {
+			t.Fatalf("expect port %d for role %s and scheme %s. Got %d", item.expected, item.role, item.scheme, port)
+		}
+	}
+}
+
+func TestComponentCheckFakeCluster(t *testing.T) {
+	cluster := fakecluster.New(fakecluster.UnhealthyUnit())
+	defer cluster.Close()
+
+	for _, testCase := range []struct {
+		ip     string
+		role   string
+		status int
+	}{
+		{"10.0.0.1", "master", constants.StatusOK},
+		{"10.0.1.2", "agent", constants.StatusOK},
+		{"10.0.1.1", "agent", constants.StatusFailure},
+	} {
+		flags := pflag.NewFlagSet("components", pflag.ContinueOnError)
+		addFlags(flags)
+
+		check, err := newCheckFromFlags(flags, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cfg := &common.CLIConfigFlags{NodeIPStr: testCase.ip, Role: testCase.role}
+		output, status, err := check.Run(cluster.Context(context.TODO()), cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if status != testCase.status {
+			t.Fatalf("node %s: expect status %d. Got %d: %s", testCase.ip, testCase.status, status, output)
+		}
+	}
+}
+
+func TestComponentCheckClusterWide(t *testing.T) {
+	scenario := fakecluster.UnhealthyUnit()
+	node, _ := scenario.Node("10.0.1.2")
+	for i := range node.Units {
+		if node.Units[i].ID == "dcos-diagnostics.socket" {
+			node.Units[i].Health = constants.StatusFailure
+		}
+	}
+
+	cluster := fakecluster.New(scenario)
+	defer cluster.Close()
+
+	flags := pflag.NewFlagSet("components", pflag.ContinueOnError)
+	addFlags(flags)
+	if err := flags.Parse([]string{"--cluster-wide"}); err != nil {
+		t.Fatal(err)
+	}
+
+	check, err := newCheckFromFlags(flags, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tags := check.(common.Tagger).Tags(); len(tags) != 1 || tags[0] != "cluster" {
+		t.Fatalf("expect the cluster-wide check to be tagged cluster. Got %v", tags)
+	}
+
+	cfg := &common.CLIConfigFlags{NodeIPStr: "10.0.0.1", Role: "master"}
+	result := check.(*componentCheck).RunDetailed(cluster.Context(context.TODO()), cfg)
+	if result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	expected := `2 components are unhealthy on 2 of 6 nodes
+by unit:
+  dcos-diagnostics.socket (DC/OS Diagnostics Agent Socket): 10.0.1.2
+  dcos-mesos-slave.service (Mesos Agent): 10.0.1.1
+by node:
+  10.0.1.1 (agent): dcos-mesos-slave.service
+  10.0.1.2 (agent): dcos-diagnostics.socket`
+	if result.Status != constants.StatusFailure || result.Output() != expected {
+		t.Fatalf("expect status %d and output:\n%s\nGot %d:\n%s", constants.StatusFailure, expected, result.Status, result.Output())
+	}
+
+	if len(result.Items) != 2 || len(result.Remediation) != 2 {
+		t.Fatalf("expect an item and a remediation for each unhealthy unit. Got %+v", result)
+	}
+
+	if len(result.PerfData) != 2 || result.PerfData[0].String() != "failed_nodes=2;;0;0;6" ||
+		result.PerfData[1].String() != "warning_nodes=0;0;;0;6" {
+		t.Fatalf("expect 2 failed nodes. Got %+v", result.PerfData)
+	}
+
+	check.(*componentCheck).Exclude = []string{"dcos-diagnostics.socket", "dcos-mesos-slave.service"}
+	result = check.(*componentCheck).RunDetailed(cluster.Context(context.TODO()), cfg)
+	if result.Status != constants.StatusOK || result.Summary != "all components are healthy on 6 nodes" {
+		t.Fatalf("expect excluded units to be ignored. Got %d: %s %v", result.Status, result.Output(), result.Err)
+	}
+
+	cfg.Role = "agent"
+	if result = check.(*componentCheck).RunDetailed(cluster.Context(context.TODO()), cfg); result.Status != constants.StatusUnknown {
+		t.Fatalf("expect cluster health to be unavailable on agents. Got %d", result.Status)
+	}
+}
+
+func TestComponentCheckWarning(t *testing.T) {
+	cluster := fakecluster.New(fakecluster.UnhealthyUnit())
+	defer cluster.Close()
+
+	flags := pflag.NewFlagSet("components", pflag.ContinueOnError)
+	addFlags(flags)
+	if err := flags.Parse([]string{"--include", "dcos-mesos-*", "--warning", "/slave/"}); err != nil {
+		t.Fatal(err)
+	}
+
+	check, err := newCheckFromFlags(flags, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &common.CLIConfigFlags{NodeIPStr: "10.0.1.1", Role: "agent"}
+	result := check.(*componentCheck).RunDetailed(cluster.Context(context.TODO()), cfg)
+	if result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	expected := "component Mesos Agent has health status 1: dcos-mesos-slave.service: main process exited, code=exited, status=1/FAILURE"
+	if result.Status != constants.StatusWarning || result.Output() != expected {
+		t.Fatalf("expect status %d and output %q. Got %d: %q", constants.StatusWarning, expected, result.Status, result.Output())
+	}
+
+	if len(result.Items) != 1 || result.Items[0].Name != "dcos-mesos-slave.service" {
+		t.Fatalf("expect an item for the included unit only. Got %+v", result.Items)
+	}
+
+	// the unit mapped to a warning must not exceed the critical threshold.
+	var perfData []string
+	for _, p := range result.PerfData {
+		perfData = append(perfData, p.String())
+	}
+	expectedPerfData := "failed_units=0;;0;0;3 warning_units=1;0;;0;3"
+	if strings.Join(perfData, " ") != expectedPerfData {
+		t.Fatalf("expect perf data %q. Got %q", expectedPerfData, strings.Join(perfData, " "))
+	}
+}
+
+func TestNewCheckFromFlagsInvalidPattern(t *testing.T) {
+	flags := pflag.NewFlagSet("components", pflag.ContinueOnError)
+	addFlags(flags)
+	if err := flags.Parse([]string{"--exclude", "dcos-[*"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := newCheckFromFlags(flags, nil); err == nil {
+		t.Fatal("expect an error for an invalid unit pattern")
+	}
 }
@@ -2,7 +2,10 @@ package components
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
+	"github.com/dcos/dcos-checks/common"
 	"github.com/dcos/dcos-checks/constants"
 )
 
@@ -17,21 +20,113 @@ type diagnosticsResponse struct {
 	} `json:"units"`
 }
 
-func (d *diagnosticsResponse) checkHealth(skipCompArgs []string) ([]string, int) {
-	var errorList []string
-	skipCompMap := make(map[string]bool, len(skipCompArgs))
-	for _, unit := range skipCompArgs {
-		skipCompMap[unit] = true
+// items returns a result for each unit which is not skipped.
+func (d *diagnosticsResponse) items(filter *unitFilter) []common.ItemResult {
+	var items []common.ItemResult
+	for _, unit := range d.Units {
+		if filter.skip(unit.ID) {
+			continue
+		}
+
+		items = append(items, common.ItemResult{
+			Name:   unit.ID,
+			Status: filter.status(unit.ID, unit.Health),
+			Output: unitMessage(unit.Name, unit.Health, unit.Output, unit.Help),
+		})
 	}
+	return items
+}
 
+// remediation returns a hint for each unhealthy unit which is not skipped. The hint is the
+// unit help text reported by dcos-diagnostics.
+func (d *diagnosticsResponse) remediation(filter *unitFilter) []common.Remediation {
+	var remediation []common.Remediation
 	for _, unit := range d.Units {
-		if (unit.Health != constants.StatusOK) && !skipCompMap[unit.ID] {
-			errorList = append(errorList, fmt.Sprintf("component %s has health status %d", unit.Name, unit.Health))
+		if unit.Health == constants.StatusOK || filter.skip(unit.ID) {
+			continue
+		}
+
+		hint := unit.Help
+		if hint == "" {
+			hint = fmt.Sprintf("inspect the unit logs with journalctl -u %s and restart it", unit.ID)
 		}
+
+		remediation = append(remediation, common.Remediation{
+			Hint:    fmt.Sprintf("%s: %s", unit.Name, hint),
+			Command: fmt.Sprintf("systemctl restart %s", unit.ID),
+		})
 	}
+	return remediation
+}
+
+// checkHealth returns a message for each unhealthy unit which is not skipped and the worst
+// status of the units.
+func (d *diagnosticsResponse) checkHealth(filter *unitFilter) ([]string, int) {
+	var errorList []string
 	retCode := constants.StatusOK
-	if len(errorList) > 0 {
-		retCode = constants.StatusFailure
+
+	for _, unit := range d.Units {
+		if unit.Health == constants.StatusOK || filter.skip(unit.ID) {
+			continue
+		}
+
+		errorList = append(errorList, unitMessage(unit.Name, unit.Health, unit.Output, unit.Help))
+		if status := filter.status(unit.ID, unit.Health); status > retCode {
+			retCode = status
+		}
 	}
 	return errorList, retCode
 }
+
+// statusCounts returns the numbers of unhealthy units which are not skipped, mapped to a warning
+// and to a failure by the filter.
+func (d *diagnosticsResponse) statusCounts(filter *unitFilter) (warning, failure int) {
+	for _, unit := range d.Units {
+		if unit.Health == constants.StatusOK || filter.skip(unit.ID) {
+			continue
+		}
+
+		if filter.status(unit.ID, unit.Health) == constants.StatusWarning {
+			warning++
+		} else {
+			failure++
+		}
+	}
+	return warning, failure
+}
+
+// statusPerfData returns the performance data of the numbers of units or nodes mapped to a warning
+// and to a failure. Only failures exceed the critical threshold, so units mapped to a warning by
+// --warning do not raise critical alerts.
+func statusPerfData(name string, warning, failure, total int) []common.PerfData {
+	return []common.PerfData{
+		{
+			Label:    "failed_" + name,
+			Value:    float64(failure),
+			Critical: "0",
+			Min:      "0",
+			Max:      strconv.Itoa(total),
+		},
+		{
+			Label:   "warning_" + name,
+			Value:   float64(warning),
+			Warning: "0",
+			Min:     "0",
+			Max:     strconv.Itoa(total),
+		},
+	}
+}
+
+// unitMessage returns a one line description of the unit health including the unit output
+// and help reported by dcos-diagnostics.
+func unitMessage(name string, health int, output, help string) string {
+	msg := fmt.Sprintf("component %s has health status %d", name, health)
+	if output = strings.Join(strings.Fields(output), " "); output != "" {
+		msg += ": " + output
+	}
+
+	if help != "" {
+		msg += fmt.Sprintf(" (help: %s)", help)
+	}
+	return msg
+}
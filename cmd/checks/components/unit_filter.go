@@ -0,0 +1,109 @@
+package components
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/dcos/dcos-checks/constants"
+	"github.com/pkg/errors"
+)
+
+// unitPattern matches unit IDs. A pattern enclosed in slashes, e.g. /^dcos-.*-exporter/, is a
+// regular expression, any other pattern is a glob, e.g. dcos-*-exporter*. A pattern without
+// wildcards matches the unit ID exactly.
+type unitPattern struct {
+	glob string
+	re   *regexp.Regexp
+}
+
+// compilePatterns returns the unit patterns or an error if any of them is invalid.
+func compilePatterns(patterns []string) ([]unitPattern, error) {
+	compiled := make([]unitPattern, 0, len(patterns))
+	for _, pattern := range patterns {
+		if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+			re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid unit pattern %s", pattern)
+			}
+			compiled = append(compiled, unitPattern{re: re})
+			continue
+		}
+
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, errors.Wrapf(err, "invalid unit pattern %s", pattern)
+		}
+		compiled = append(compiled, unitPattern{glob: pattern})
+	}
+	return compiled, nil
+}
+
+// match returns true if the unit ID matches the pattern.
+func (p unitPattern) match(id string) bool {
+	if p.re != nil {
+		return p.re.MatchString(id)
+	}
+
+	// the pattern is validated by compilePatterns.
+	matched, _ := path.Match(p.glob, id)
+	return matched
+}
+
+// matchAny returns true if the unit ID matches any of the patterns.
+func matchAny(patterns []unitPattern, id string) bool {
+	for _, pattern := range patterns {
+		if pattern.match(id) {
+			return true
+		}
+	}
+	return false
+}
+
+// unitFilter selects the checked units and maps the health of unhealthy units to a check status.
+type unitFilter struct {
+	include []unitPattern
+	exclude []unitPattern
+	warning []unitPattern
+}
+
+// newUnitFilter returns a filter checking the units matching include, or all units if include is
+// empty, except the units matching exclude. Unhealthy units matching warning are reported as warnings.
+func newUnitFilter(include, exclude, warning []string) (*unitFilter, error) {
+	var (
+		f   unitFilter
+		err error
+	)
+
+	if f.include, err = compilePatterns(include); err != nil {
+		return nil, err
+	}
+
+	if f.exclude, err = compilePatterns(exclude); err != nil {
+		return nil, err
+	}
+
+	if f.warning, err = compilePatterns(warning); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// skip returns true if the unit is not checked.
+func (f *unitFilter) skip(id string) bool {
+	if len(f.include) > 0 && !matchAny(f.include, id) {
+		return true
+	}
+	return matchAny(f.exclude, id)
+}
+
+// status returns the check status of a unit with the given dcos-diagnostics health.
+func (f *unitFilter) status(id string, health int) int {
+	switch {
+	case health == constants.StatusOK:
+		return constants.StatusOK
+	case matchAny(f.warning, id):
+		return constants.StatusWarning
+	default:
+		return constants.StatusFailure
+	}
+}
@@ -0,0 +1,49 @@
+package components
+
+import (
+	"testing"
+
+	"github.com/dcos/dcos-checks/constants"
+)
+
+func TestUnitFilter(t *testing.T) {
+	filter, err := newUnitFilter(
+		[]string{"dcos-*", "/^mesos-.*\\.service$/"},
+		[]string{"dcos-checks-poststart.*"},
+		[]string{"dcos-*-exporter*"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, item := range []struct {
+		id     string
+		health int
+		skip   bool
+		status int
+	}{
+		{id: "dcos-adminrouter.service", health: 1, status: constants.StatusFailure},
+		{id: "dcos-adminrouter.service", health: 0, status: constants.StatusOK},
+		{id: "dcos-checks-poststart.timer", health: 1, skip: true, status: constants.StatusFailure},
+		{id: "dcos-telegraf-exporter.service", health: 1, status: constants.StatusWarning},
+		{id: "mesos-dns.service", health: 1, status: constants.StatusFailure},
+		{id: "mesos-dns.socket", health: 1, skip: true, status: constants.StatusFailure},
+		{id: "docker.service", health: 1, skip: true, status: constants.StatusFailure},
+	} {
+		if skip := filter.skip(item.id); skip != item.skip {
+			t.Fatalf("expect skip %t for unit %s. Got %t", item.skip, item.id, skip)
+		}
+
+		if status := filter.status(item.id, item.health); status != item.status {
+			t.Fatalf("expect status %d for unit %s with health %d. Got %d", item.status, item.id, item.health, status)
+		}
+	}
+}
+
+func TestUnitFilterInvalidPattern(t *testing.T) {
+	for _, patterns := range [][]string{{"dcos-[*"}, {"/dcos-(/"}} {
+		if _, err := newUnitFilter(nil, patterns, nil); err == nil {
+			t.Fatalf("expect an error for patterns %v", patterns)
+		}
+	}
+}
@@ -17,27 +17,25 @@ package executable
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/dcos/dcos-checks/common"
 	"github.com/dcos/dcos-checks/constants"
 	"github.com/dcos/dcos-go/exec"
-	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
-// executableCmd represents the executable command
-var executableCmd = &cobra.Command{
-	Use:   "executable",
-	Short: "Check for the availability of an executable",
-	Long:  "Check for the availability of an executable",
-	Run: func(cmd *cobra.Command, args []string) {
-		common.RunCheck(context.TODO(),
-			newExecutableCheck("check availability of executable", args))
-	},
-}
-
-// Register adds this command to the root command
-func Register(root *cobra.Command) {
-	root.AddCommand(executableCmd)
+func init() {
+	common.RegisterCheck(common.CheckSpec{
+		Name:        "executable",
+		Description: "Check for the availability of an executable",
+		Long:        "Check for the availability of an executable",
+		Tags:        []string{"node", "exec"},
+		Timeout:     5 * time.Second,
+		New: func(flags *pflag.FlagSet, args []string) (common.DCOSChecker, error) {
+			return newExecutableCheck("check availability of executable", args), nil
+		},
+	})
 }
 
 // newExecutableCheck returns an intialized instance of *executableCheck
@@ -61,31 +59,64 @@ func (c *executableCheck) ID() string {
 
 // Run the binary check
 func (c *executableCheck) Run(ctx context.Context, cfg *common.CLIConfigFlags) (string, int, error) {
-	err := c.executableExists(ctx, cfg)
+	return c.RunDetailed(ctx, cfg).Tuple()
+}
+
+// RunDetailed runs the binary check and returns an installation hint as remediation if
+// the executable is not found.
+func (c *executableCheck) RunDetailed(ctx context.Context, cfg *common.CLIConfigFlags) common.CheckResult {
+	remediation, err := c.executableExists(ctx, cfg)
+	if err != nil {
+		return common.CheckResult{Status: constants.StatusFailure, Err: err, Remediation: remediation}
+	}
+	return common.CheckResult{Status: constants.StatusOK}
+}
+
+// Explain returns the command used to look up the executable.
+func (c *executableCheck) Explain(ctx context.Context, cfg *common.CLIConfigFlags) ([]common.Action, error) {
+	command, err := c.command()
 	if err != nil {
-		return "", constants.StatusFailure, err
+		return nil, err
 	}
-	return "", constants.StatusOK, nil
+
+	return []common.Action{{
+		Kind:        common.ActionExec,
+		Description: "look up the executable in PATH",
+		Command:     command,
+	}}, nil
 }
 
-func (c *executableCheck) executableExists(ctx context.Context, cfg *common.CLIConfigFlags) error {
+// command returns the command which succeeds if the executable is available.
+func (c *executableCheck) command() ([]string, error) {
 	var args = c.Args
 
 	if len(args) == 0 {
-		return fmt.Errorf("No executable to check")
+		return nil, fmt.Errorf("No executable to check")
 	}
 
 	if len(args) > 1 {
-		return fmt.Errorf("Only one executable allowed at a time")
+		return nil, fmt.Errorf("Only one executable allowed at a time")
+	}
+
+	return []string{"bash", "-c", fmt.Sprintf("command -v %s", args[0])}, nil
+}
+
+// executableExists returns an error and an installation hint if the executable is not available.
+func (c *executableCheck) executableExists(ctx context.Context, cfg *common.CLIConfigFlags) ([]common.Remediation, error) {
+	command, err := c.command()
+	if err != nil {
+		return nil, err
 	}
 
-	_, _, exitCode, err := exec.FullOutput(exec.CommandContext(ctx, "bash", "-c", fmt.Sprintf("command -v %s", args[0])))
+	_, _, exitCode, err := exec.FullOutput(exec.CommandContext(ctx, command...))
 	if err != nil {
-		return fmt.Errorf("ERROR: Unable to determine whether %s is available", args[0])
+		return nil, fmt.Errorf("ERROR: Unable to determine whether %s is available", c.Args[0])
 	}
 	if exitCode != 0 {
-		return fmt.Errorf("%s not available", args[0])
+		return []common.Remediation{{
+			Hint: fmt.Sprintf("Install %s and make sure it is in PATH", c.Args[0]),
+		}}, fmt.Errorf("%s not available", c.Args[0])
 	}
 
-	return nil
+	return nil, nil
 }
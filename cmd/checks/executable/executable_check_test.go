@@ -7,8 +7,8 @@ import (
 	"github.com/dcos/dcos-checks/common"
 )
 
-func checkExecutable(e string) error {
-	c := &executableCheck{"Test", []string{e}}
+func checkExecutable(e string) ([]common.Remediation, error) {
+	c := newExecutableCheck("Test", []string{e})
 	mockCLICfg := &common.CLIConfigFlags{
 		NodeIPStr: "127.0.0.1",
 		Role:      "master",
@@ -21,13 +21,18 @@ func checkExecutable(e string) error {
 func TestExecutableExists(t *testing.T) {
 	// negative test case
 	executable := "nonexistent_executable"
-	if err := checkExecutable(executable); err == nil {
+	remediation, err := checkExecutable(executable)
+	if err == nil {
 		t.Fatalf("unexpectedly found executable '%s'", executable)
 	}
 
+	if len(remediation) != 1 {
+		t.Fatalf("expect an installation hint. Got %v", remediation)
+	}
+
 	// positive test case
 	executable = "bash"
-	if err := checkExecutable(executable); err != nil {
+	if remediation, err = checkExecutable(executable); err != nil || len(remediation) != 0 {
 		t.Fatalf("executable '%s' not found", executable)
 	}
 }
@@ -23,73 +23,137 @@ import (
 
 	"github.com/dcos/dcos-checks/common"
 	"github.com/dcos/dcos-checks/constants"
+	"github.com/dcos/dcos-go/dcos"
 	"github.com/dcos/dcos-go/exec"
 	"github.com/pkg/errors"
-	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 const defaultDetectIP = "/opt/mesosphere/bin/detect_ip"
 
-var detectIP string
-
-// ipCmd represents the ip command
-var ipCmd = &cobra.Command{
-	Use:   "ip",
-	Short: "Validate `detect_ip` output",
-	Long:  `detect_ip is used to determine the node IP address.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
-		defer cancel()
-		common.RunCheck(ctx, newDetectIPCheck(detectIP))
-	},
-}
-
-// Register adds this command to the root command
-func Register(root *cobra.Command) {
-	root.AddCommand(ipCmd)
-	ipCmd.Flags().StringVarP(&detectIP, "detect-ip", "d", defaultDetectIP, "Set path to detect_ip script")
+func init() {
+	common.RegisterCheck(common.CheckSpec{
+		Name:        "ip",
+		Description: "Validate `detect_ip` output",
+		Long:        `detect_ip is used to determine the node IP address.`,
+		Roles:       []string{dcos.RoleMaster, dcos.RoleAgent, dcos.RoleAgentPublic},
+		Tags:        []string{"node", "exec"},
+		Timeout:     time.Second,
+		Flags: func(flags *pflag.FlagSet) {
+			flags.StringP("detect-ip", "d", "", "Set path to detect_ip script (default is the global --detect-ip)")
+		},
+		New: func(flags *pflag.FlagSet, args []string) (common.DCOSChecker, error) {
+			path, err := flags.GetString("detect-ip")
+			if err != nil {
+				return nil, err
+			}
+			return newDetectIPCheck(path), nil
+		},
+	})
 }
 
 // newDetectIPCheck returns a new instance of detectIPCheck.
 func newDetectIPCheck(path string) *detectIPCheck {
-	return &detectIPCheck{path}
+	return &detectIPCheck{Path: path}
 }
 
-// detectIPCheck is a structure to accommodate detect_ip check.
+// detectIPCheck is a structure to accommodate detect_ip check. The node IP address set with the
+// global --node-ip takes precedence over detect_ip. Path is empty unless the check parameter is set,
+// e.g. by a suite, or by the ip subcommand where it shadows the global --detect-ip.
 type detectIPCheck struct {
 	Path string
 }
 
 // ID returns check ID.
 func (d *detectIPCheck) ID() string {
+	if d.Path == "" {
+		return "detect_ip check"
+	}
 	return "detect_ip check " + d.Path
 }
 
+// path returns the detect_ip script executed by the check: Path if set, otherwise the global
+// --detect-ip used by the other checks, falling back to the default script.
+func (d *detectIPCheck) path(cfg *common.CLIConfigFlags) string {
+	if d.Path != "" {
+		return d.Path
+	}
+
+	if cfg != nil && cfg.DetectIP != "" {
+		return cfg.DetectIP
+	}
+	return defaultDetectIP
+}
+
+// Explain returns the detect_ip script executed by the check. Nothing is executed if the node IP
+// address is set with --node-ip.
+func (d *detectIPCheck) Explain(ctx context.Context, cfg *common.CLIConfigFlags) ([]common.Action, error) {
+	if cfg != nil && cfg.NodeIPStr != "" {
+		return nil, nil
+	}
+
+	return []common.Action{{
+		Kind:        common.ActionExec,
+		Description: "detect the node IP address",
+		Command:     []string{d.path(cfg)},
+	}}, nil
+}
+
 // Run executes the check.
 func (d *detectIPCheck) Run(ctx context.Context, cfg *common.CLIConfigFlags) (string, int, error) {
-	if d.Path == "" {
-		return "", constants.StatusUnknown, errors.New("path must be set")
+	return d.RunDetailed(ctx, cfg).Tuple()
+}
+
+// RunDetailed validates the node IP address set by --node-ip, or executes detect_ip and returns
+// a hint as remediation if the script does not follow the detect_ip contract.
+func (d *detectIPCheck) RunDetailed(ctx context.Context, cfg *common.CLIConfigFlags) common.CheckResult {
+	if cfg != nil && cfg.NodeIPStr != "" {
+		ip, err := cfg.IP(nil)
+		if err != nil {
+			return common.CheckResult{Status: constants.StatusFailure, Err: err}
+		}
+
+		return common.CheckResult{
+			Status:  constants.StatusOK,
+			Summary: fmt.Sprintf("%s is a valid IP address set by --node-ip", ip),
+		}
 	}
 
-	stdout, stderr, code, err := exec.FullOutput(exec.CommandContext(ctx, d.Path))
+	path := d.path(cfg)
+	stdout, stderr, code, err := exec.FullOutput(exec.CommandContext(ctx, path))
 	if err != nil {
-		return "", constants.StatusUnknown, err
+		return common.CheckResult{Status: constants.StatusUnknown, Err: err}
+	}
+
+	// every failure below means the script does not follow the detect_ip contract.
+	contractResult := func(status int, err error) common.CheckResult {
+		return common.CheckResult{
+			Status: status,
+			Err:    err,
+			Remediation: []common.Remediation{{
+				Hint:    "detect_ip must print the IP address of the node to stdout, nothing to stderr, and exit with 0",
+				Command: path,
+			}},
+		}
 	}
 
 	if code != 0 {
-		return "", code, errors.Wrapf(err, "return code non zero: %d", code)
+		return contractResult(code, errors.Wrapf(err, "return code non zero: %d", code))
 	}
 
 	if len(stderr) > 0 {
-		return "", constants.StatusFailure, errors.Errorf("detect_ip returned stderr: %s", string(stderr))
+		return contractResult(constants.StatusFailure, errors.Errorf("detect_ip returned stderr: %s", string(stderr)))
 	}
 
 	trimmedIP := bytes.TrimSpace(stdout)
 
 	ip := net.ParseIP(string(trimmedIP))
 	if ip == nil {
-		return "", constants.StatusUnknown, errors.Errorf("invalid IP address %s", stdout)
+		return contractResult(constants.StatusUnknown, errors.Errorf("invalid IP address %s", stdout))
 	}
 
-	return fmt.Sprintf("%s is a valid IPV4 address", ip), constants.StatusOK, nil
+	return common.CheckResult{
+		Status:  constants.StatusOK,
+		Summary: fmt.Sprintf("%s is a valid IPV4 address", ip),
+	}
 }
@@ -5,26 +5,91 @@ import (
 	"testing"
 
 	"github.com/dcos/dcos-checks/common"
+	"github.com/dcos/dcos-checks/constants"
 )
 
 func TestDetectIPCheck_Run(t *testing.T) {
 	mockCLICfg := &common.CLIConfigFlags{}
 
-	check := detectIPCheck{"./fixture/detect_ip.bad"}
+	check := detectIPCheck{Path: "./fixture/detect_ip.bad"}
 	_, _, err := check.Run(context.TODO(), mockCLICfg)
 	if err == nil {
 		t.Fatal("expect error")
 	}
 
-	check = detectIPCheck{"./fixture/detect_ip.good"}
+	check = detectIPCheck{Path: "./fixture/detect_ip.good"}
 	_, _, err = check.Run(context.TODO(), mockCLICfg)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	check = detectIPCheck{"./fixture/detect_ip.empty"}
+	check = detectIPCheck{Path: "./fixture/detect_ip.empty"}
 	_, _, err = check.Run(context.TODO(), mockCLICfg)
 	if err == nil {
 		t.Fatal("expect error")
 	}
 }
+
+func TestDetectIPCheckGlobalSettings(t *testing.T) {
+	for _, testCase := range []struct {
+		name   string
+		path   string
+		cfg    *common.CLIConfigFlags
+		status int
+		output string
+	}{
+		{
+			name:   "node ip",
+			path:   "./fixture/detect_ip.bad",
+			cfg:    &common.CLIConfigFlags{NodeIPStr: "127.0.0.1", DetectIP: "./fixture/detect_ip.bad"},
+			status: constants.StatusOK,
+			output: "127.0.0.1 is a valid IP address set by --node-ip",
+		},
+		{
+			name:   "invalid node ip",
+			cfg:    &common.CLIConfigFlags{NodeIPStr: "not-an-ip", DetectIP: "./fixture/detect_ip.good"},
+			status: constants.StatusFailure,
+		},
+		{
+			name:   "global detect_ip",
+			cfg:    &common.CLIConfigFlags{DetectIP: "./fixture/detect_ip.good"},
+			status: constants.StatusOK,
+			output: "127.0.0.1 is a valid IPV4 address",
+		},
+		{
+			name:   "check parameter",
+			path:   "./fixture/detect_ip.good",
+			cfg:    &common.CLIConfigFlags{DetectIP: "./fixture/detect_ip.bad"},
+			status: constants.StatusOK,
+			output: "127.0.0.1 is a valid IPV4 address",
+		},
+	} {
+		check := newDetectIPCheck(testCase.path)
+		output, status, _ := check.Run(context.TODO(), testCase.cfg)
+		if status != testCase.status || output != testCase.output {
+			t.Fatalf("%s: expect status %d and output %q. Got %d: %q", testCase.name, testCase.status,
+				testCase.output, status, output)
+		}
+	}
+}
+
+func TestDetectIPCheckExplainNodeIP(t *testing.T) {
+	check := newDetectIPCheck("")
+	actions, err := check.Explain(context.TODO(), &common.CLIConfigFlags{NodeIPStr: "127.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(actions) != 0 {
+		t.Fatalf("expect detect_ip not to be executed with --node-ip. Got %+v", actions)
+	}
+
+	actions, err = check.Explain(context.TODO(), &common.CLIConfigFlags{DetectIP: "/tmp/detect_ip"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(actions) != 1 || actions[0].Command[0] != "/tmp/detect_ip" {
+		t.Fatalf("expect the global detect_ip to be executed. Got %+v", actions)
+	}
+}
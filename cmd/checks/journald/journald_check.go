@@ -22,9 +22,10 @@ import (
 
 	"github.com/dcos/dcos-checks/common"
 	"github.com/dcos/dcos-checks/constants"
+	"github.com/dcos/dcos-go/dcos"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
-	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 const (
@@ -49,42 +50,27 @@ type journalCheck struct {
 	checkDirFn checkDirectoryFn
 }
 
-// journaldCmd represents the journald command
-var journaldCmd = &cobra.Command{
-	Use:   "journald",
-	Short: "Check if the journal folder ownership and permissions",
-	Long: `Check if the journal folder is owned by root:systemd-journal and has r-x group permissions.
+// the default location for journal is /var/log/journal, however if the folder is there,
+// journald will write to /run/log/journal in a nonpersistent way.
+var systemJournalPaths = []string{"/var/log/journal", "/run/log/journal"}
+
+func init() {
+	common.RegisterCheck(common.CheckSpec{
+		Name:        "journald",
+		Description: "Check if the journal folder ownership and permissions",
+		Long: `Check if the journal folder is owned by root:systemd-journal and has r-x group permissions.
 
 If a user does not set the --path parameter, check will try to use default locations:
  - /var/log/journal
  - /run/log/journal
 	`,
-	Run: func(cmd *cobra.Command, args []string) {
-		if userJournalPath == "" {
-			var err error
-			userJournalPath, err = getJournalPath(systemJournalPaths)
-			if err != nil {
-				logrus.Fatal(err)
-			}
-		}
-
-		common.RunCheck(context.TODO(), newJournalCheck(userJournalPath))
-	},
-}
-
-var (
-	// the default location for journal is /var/log/journal, however if the folder is there,
-	// journald will write to /run/log/journal in a nonpersistent way.
-	systemJournalPaths = []string{"/var/log/journal", "/run/log/journal"}
-
-	userJournalPath string
-)
-
-// Register adds this command to the root command
-func Register(root *cobra.Command) {
-	root.AddCommand(journaldCmd)
-	journaldCmd.Flags().StringVarP(&userJournalPath, "path", "p", "",
-		"Set a path to systemd journal binary log directory.")
+		Roles: []string{dcos.RoleMaster, dcos.RoleAgent, dcos.RoleAgentPublic},
+		Tags:  []string{"node", "file"},
+		Flags: func(flags *pflag.FlagSet) {
+			flags.StringP("path", "p", "", "Set a path to systemd journal binary log directory.")
+		},
+		New: newCheckFromFlags,
+	})
 }
 
 func (j *journalCheck) checkDirectory(path string, group uint32, bits map[string]uint32) error {
@@ -93,15 +79,12 @@ func (j *journalCheck) checkDirectory(path string, group uint32, bits map[string
 		return err
 	}
 
-	helpMsg := fmt.Sprintf("\nTry to run: systemd-tmpfiles --create --prefix %s", path)
-
 	perm := dirStat.Mode().Perm()
 	logrus.Debugf("folder %s full permissions: %s", path, perm)
 
 	for description, bit := range bits {
 		if uint32(perm)&bit == 0 {
-			return errors.Errorf("directory %s has wrong permissions: %s bit must be set. \n%s",
-				path, description, helpMsg)
+			return errors.Errorf("directory %s has wrong permissions: %s bit must be set", path, description)
 		}
 	}
 
@@ -110,7 +93,7 @@ func (j *journalCheck) checkDirectory(path string, group uint32, bits map[string
 		return errors.New("unable to type assert to syscall.Stat_t")
 	}
 	if stat.Gid != group {
-		return errors.Errorf("directory %s must be in group with Gid %d.%s", path, group, helpMsg)
+		return errors.Errorf("directory %s must be in group with Gid %d", path, group)
 	}
 	logrus.Debug("directory is in the right group")
 
@@ -122,25 +105,74 @@ func (j *journalCheck) ID() string {
 	return "systemd journal check"
 }
 
+// journalPath returns the journal path set by the user or the first existing default location.
+func (j *journalCheck) journalPath() (string, error) {
+	if j.Path != "" {
+		return j.Path, nil
+	}
+	return getJournalPath(systemJournalPaths)
+}
+
+// Explain returns the journal directory inspected by the check.
+func (j *journalCheck) Explain(ctx context.Context, cfg *common.CLIConfigFlags) ([]common.Action, error) {
+	path, err := j.journalPath()
+	if err != nil {
+		return nil, err
+	}
+
+	return []common.Action{{
+		Kind:        common.ActionFile,
+		Description: fmt.Sprintf("group owner %s and group r-x permissions", systemdJournalGroup),
+		File:        path,
+	}}, nil
+}
+
 // Run the journal check.
 func (j *journalCheck) Run(ctx context.Context, cfg *common.CLIConfigFlags) (string, int, error) {
-	if j.Path == "" {
-		return "", constants.StatusUnknown, errors.New("journald path is not set")
+	return j.RunDetailed(ctx, cfg).Tuple()
+}
+
+// RunDetailed checks the journal directory and returns the command restoring it as remediation
+// if it has a wrong group owner or permissions.
+func (j *journalCheck) RunDetailed(ctx context.Context, cfg *common.CLIConfigFlags) common.CheckResult {
+	path, err := j.journalPath()
+	if err != nil {
+		return common.CheckResult{Status: constants.StatusUnknown, Err: err}
 	}
 
-	var err error
 	gid, err := j.lookupGroup.gid()
 	if err != nil {
-		return "", constants.StatusUnknown, err
+		return common.CheckResult{Status: constants.StatusUnknown, Err: err}
 	}
 
-	err = j.checkDirFn(j.Path, gid, j.checkBits)
+	err = j.checkDirFn(path, gid, j.checkBits)
+	if err != nil {
+		return common.CheckResult{
+			Status: constants.StatusUnknown,
+			Err:    err,
+			Remediation: []common.Remediation{{
+				Hint:    fmt.Sprintf("Recreate the journal directory with the group owner %s and group r-x permissions", systemdJournalGroup),
+				Link:    "https://www.freedesktop.org/software/systemd/man/systemd-tmpfiles.html",
+				Command: fmt.Sprintf("systemd-tmpfiles --create --prefix %s", path),
+			}},
+		}
+	}
+
+	return common.CheckResult{
+		Status:  constants.StatusOK,
+		Summary: fmt.Sprintf("directory %s has the group owner `systemd-journal` and group permissons r-x", path),
+	}
+}
+
+// newCheckFromFlags returns a journal check configured with the given flags. If the journal
+// path is not set, the default locations are resolved when the check is executed.
+func newCheckFromFlags(flags *pflag.FlagSet, args []string) (common.DCOSChecker, error) {
+	path, err := flags.GetString("path")
 	if err != nil {
-		return "", constants.StatusUnknown, err
+		return nil, err
 	}
 
-	return fmt.Sprintf("directory %s has the group owner `systemd-journal` and group permissons r-x", j.Path),
-		constants.StatusOK, nil
+	return newJournalCheck(path), nil
 }
 
 // newJournalCheck returns an initialized instance of journalCheck.
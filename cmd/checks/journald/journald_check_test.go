@@ -8,6 +8,7 @@ import (
 
 	"github.com/dcos/dcos-checks/constants"
 	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
 )
 
 func mockCheckDirFn(err error) checkDirectoryFn {
@@ -23,7 +24,8 @@ func TestJournalCheckFailure(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	output, code, err := c.Run(context.TODO(), nil)
+	result := c.RunDetailed(context.TODO(), nil)
+	output, code, err := result.Tuple()
 	if output != "" {
 		t.Fatalf("expected empty output. Got %s", output)
 	}
@@ -35,11 +37,17 @@ func TestJournalCheckFailure(t *testing.T) {
 	if err != e {
 		t.Fatalf("expect error %s, got %s", e, err)
 	}
+
+	remediation := result.Remediation
+	if len(remediation) != 1 || remediation[0].Command != "systemd-tmpfiles --create --prefix /tmp" {
+		t.Fatalf("expect systemd-tmpfiles remediation. Got %v", remediation)
+	}
 }
 
 func TestJournalCheckSuccess(t *testing.T) {
 	c, err := newMockJournalCheck(nil)
-	out, code, err := c.Run(context.TODO(), nil)
+	result := c.RunDetailed(context.TODO(), nil)
+	out, code, err := result.Tuple()
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -51,6 +59,10 @@ func TestJournalCheckSuccess(t *testing.T) {
 	if out == "" {
 		t.Fatal("Expect non empty output")
 	}
+
+	if remediation := result.Remediation; len(remediation) != 0 {
+		t.Fatalf("expect no remediation. Got %v", remediation)
+	}
 }
 
 func newMockJournalCheck(e error) (*journalCheck, error) {
@@ -76,3 +88,21 @@ func newMockJournalCheck(e error) (*journalCheck, error) {
 
 	return c, nil
 }
+
+func TestJournalCheckDefaultPathMissing(t *testing.T) {
+	paths := systemJournalPaths
+	defer func() { systemJournalPaths = paths }()
+	systemJournalPaths = []string{"/nonexistent/journal"}
+
+	flags := pflag.NewFlagSet("journald", pflag.ContinueOnError)
+	flags.String("path", "", "")
+
+	check, err := newCheckFromFlags(flags, nil)
+	if err != nil {
+		t.Fatalf("expect the check to be constructed without a journal directory. Got %s", err)
+	}
+
+	if _, code, err := check.Run(context.TODO(), nil); code != constants.StatusUnknown || err == nil {
+		t.Fatalf("expect status %d and an error. Got %d: %v", constants.StatusUnknown, code, err)
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"time"
 
 	"github.com/dcos/dcos-checks/client"
 	"github.com/dcos/dcos-checks/common"
@@ -28,7 +29,7 @@ import (
 	"github.com/dcos/dcos-go/dcos"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
-	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 const (
@@ -39,22 +40,22 @@ const (
 // the value of /metrics/snapshot
 type mesosMetricsCheck struct {
 	Name    string
-	urlFunc func(*http.Client, *common.CLIConfigFlags) (*url.URL, error)
+	urlFunc func(context.Context, *http.Client, *common.CLIConfigFlags) (*url.URL, error)
 }
 
-// mesosMetricsCmd represents the mesos-metrics command
-var mesosMetricsCmd = &cobra.Command{
-	Use:   "mesos-metrics",
-	Short: "Get the mesos metrics snapshot",
-	Long:  `Metrics snapshot lets us know if the mesos rep logs are synchronized`,
-	Run: func(cmd *cobra.Command, args []string) {
-		common.RunCheck(context.TODO(), newMesosMetricsCheck("DC/OS metrics snapshot check"))
-	},
-}
-
-// Register adds this command to the root command
-func Register(root *cobra.Command) {
-	root.AddCommand(mesosMetricsCmd)
+func init() {
+	common.RegisterCheck(common.CheckSpec{
+		Name:        "mesos-metrics",
+		Description: "Get the mesos metrics snapshot",
+		Long:        `Metrics snapshot lets us know if the mesos rep logs are synchronized`,
+		Roles:       []string{dcos.RoleMaster, dcos.RoleAgent, dcos.RoleAgentPublic},
+		Tags:        []string{"node", "http", "mesos"},
+		Requires:    []string{"ip"},
+		Timeout:     10 * time.Second,
+		New: func(flags *pflag.FlagSet, args []string) (common.DCOSChecker, error) {
+			return newMesosMetricsCheck("DC/OS metrics snapshot check"), nil
+		},
+	})
 }
 
 // newMesosMetricsCheck returns an initialized instance of *mesosMetricsCheck.
@@ -71,6 +72,11 @@ func (mm *mesosMetricsCheck) ID() string {
 
 // Run invokes a check and return error output, exit code and error.
 func (mm *mesosMetricsCheck) Run(ctx context.Context, cfg *common.CLIConfigFlags) (string, int, error) {
+	return mm.RunDetailed(ctx, cfg).Tuple()
+}
+
+// RunDetailed invokes a check and returns a hint as remediation if the Mesos node is not recovered.
+func (mm *mesosMetricsCheck) RunDetailed(ctx context.Context, cfg *common.CLIConfigFlags) common.CheckResult {
 	type masterResponse struct {
 		Recovered float64 `json:"registrar/log/recovered"`
 	}
@@ -80,55 +86,97 @@ func (mm *mesosMetricsCheck) Run(ctx context.Context, cfg *common.CLIConfigFlags
 		Output    string
 	}
 
-	httpClient, err := client.NewClient(cfg.IAMConfig, cfg.CACert)
+	httpClient, err := client.NewClientContext(ctx, cfg.IAMConfig, cfg.CACert)
 	if err != nil {
-		return "", constants.StatusUnknown, errors.Wrap(err, "Unable to create HTTP client")
+		return errorResult(constants.StatusUnknown, errors.Wrap(err, "Unable to create HTTP client"))
 	}
 
-	url, err := mm.urlFunc(httpClient, cfg)
+	url, err := mm.urlFunc(ctx, httpClient, cfg)
 	if err != nil {
-		return "", constants.StatusFailure, errors.Wrap(err, "Unable to get url")
+		return errorResult(constants.StatusFailure, errors.Wrap(err, "Unable to get url"))
 	}
 
 	logrus.Debugf("GET %s", url)
 
 	req, err := http.NewRequest("GET", url.String(), nil)
 	if err != nil {
-		return "", constants.StatusUnknown, errors.Wrap(err, "Unable to create a new HTTP request")
+		return errorResult(constants.StatusUnknown, errors.Wrap(err, "Unable to create a new HTTP request"))
 	}
 
-	resp, err := httpClient.Do(req)
+	resp, err := httpClient.Do(req.WithContext(ctx))
 	if err != nil {
-		return "", constants.StatusUnknown, errors.Wrapf(err, "Unable to execute GET %s", url)
+		return errorResult(constants.StatusUnknown, errors.Wrapf(err, "Unable to execute GET %s", url))
 	}
 	defer resp.Body.Close()
 
 	if cfg.Role == dcos.RoleMaster {
 		var jsonResponse masterResponse
 		if err := json.NewDecoder(resp.Body).Decode(&jsonResponse); err != nil {
-			return "", constants.StatusUnknown, errors.Wrap(err, "Unable to unmarshal response")
+			return errorResult(constants.StatusUnknown, errors.Wrap(err, "Unable to unmarshal response"))
 		}
 
 		if jsonResponse.Recovered == nodeRecovered {
-			return "", constants.StatusOK, nil
-		}
-	} else {
-		var jsonResponse agentResponse
-		if err := json.NewDecoder(resp.Body).Decode(&jsonResponse); err != nil {
-			return "", constants.StatusUnknown, errors.Wrap(err, "Unable to unmarshal response")
+			return common.CheckResult{Status: constants.StatusOK}
 		}
 
-		if jsonResponse.Recovered == nodeRecovered {
-			return "", constants.StatusOK, nil
-		}
-		return "", constants.StatusFailure, errors.New("Mesos replog not synchronized")
+		result := errorResult(constants.StatusUnknown, errors.New("Unable to run the check"))
+		result.Remediation = []common.Remediation{{
+			Hint:    "The Mesos master replicated log has not recovered. Make sure a quorum of masters is running",
+			Command: "journalctl -u dcos-mesos-master",
+		}}
+		return result
 	}
 
-	return "", constants.StatusUnknown, errors.New("Unable to run the check")
+	var jsonResponse agentResponse
+	if err := json.NewDecoder(resp.Body).Decode(&jsonResponse); err != nil {
+		return errorResult(constants.StatusUnknown, errors.Wrap(err, "Unable to unmarshal response"))
+	}
+
+	if jsonResponse.Recovered == nodeRecovered {
+		return common.CheckResult{Status: constants.StatusOK}
+	}
+
+	result := errorResult(constants.StatusFailure, errors.New("Mesos replog not synchronized"))
+	result.Remediation = []common.Remediation{{
+		Hint:    "The Mesos agent is not registered with the master. Inspect the agent logs",
+		Command: "journalctl -u " + agentUnit(cfg.Role),
+	}}
+	return result
 }
 
-func (mm *mesosMetricsCheck) getURL(httpClient *http.Client, cfg *common.CLIConfigFlags) (*url.URL, error) {
+// errorResult returns a result of a check which found a problem or could not be completed.
+func errorResult(status int, err error) common.CheckResult {
+	return common.CheckResult{Status: status, Err: err}
+}
 
+// agentUnit returns the systemd unit of the Mesos agent on a node with the given role.
+func agentUnit(role string) string {
+	if role == dcos.RoleAgentPublic {
+		return "dcos-mesos-slave-public"
+	}
+	return "dcos-mesos-slave"
+}
+
+// Explain returns the metrics snapshot request made by the check.
+func (mm *mesosMetricsCheck) Explain(ctx context.Context, cfg *common.CLIConfigFlags) ([]common.Action, error) {
+	host, actions := common.ExplainNodeIP(cfg)
+	url, err := metricsURL(host, cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to get url")
+	}
+	return append(actions, common.HTTPAction(cfg, "GET", url, "Mesos metrics snapshot")), nil
+}
+
+func (mm *mesosMetricsCheck) getURL(ctx context.Context, httpClient *http.Client, cfg *common.CLIConfigFlags) (*url.URL, error) {
+	ip, err := common.NodeIP(ctx, cfg, httpClient)
+	if err != nil {
+		return nil, err
+	}
+	return metricsURL(ip.String(), cfg)
+}
+
+// metricsURL returns the Mesos metrics snapshot URL of the node with the given host.
+func metricsURL(host string, cfg *common.CLIConfigFlags) (*url.URL, error) {
 	portsMap := map[string]int{
 		dcos.RoleMaster:      constants.MesosMasterHTTPPort,
 		dcos.RoleAgent:       constants.MesosAgentHTTPPort,
@@ -145,14 +193,9 @@ func (mm *mesosMetricsCheck) getURL(httpClient *http.Client, cfg *common.CLIConf
 		scheme = constants.HTTPSScheme
 	}
 
-	ip, err := cfg.IP(httpClient)
-	if err != nil {
-		return nil, err
-	}
-
 	return &url.URL{
 		Scheme: scheme,
-		Host:   net.JoinHostPort(ip.String(), strconv.Itoa(port)),
+		Host:   net.JoinHostPort(host, strconv.Itoa(port)),
 		Path:   "/metrics/snapshot",
 	}, nil
 }
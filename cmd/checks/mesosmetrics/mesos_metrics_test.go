@@ -10,6 +10,7 @@ import (
 
 	"github.com/dcos/dcos-checks/common"
 	"github.com/dcos/dcos-checks/constants"
+	"github.com/dcos/dcos-checks/fakecluster"
 )
 
 // TestMesosMetricsCheckUrl verifies we get the right url
@@ -45,7 +46,7 @@ func TestMesosMetricsCheckUrl(t *testing.T) {
 			ForceTLS:  testCase.forceTLS,
 		}
 
-		url, err := test.getURL(nil, mockCLICfg)
+		url, err := test.getURL(context.TODO(), nil, mockCLICfg)
 		if err != nil {
 			t.Fatalf("Error running getURL: %s", err)
 		}
@@ -56,6 +57,32 @@ func TestMesosMetricsCheckUrl(t *testing.T) {
 	}
 }
 
+func TestMesosMetricsCheckExplain(t *testing.T) {
+	test := &mesosMetricsCheck{
+		Name: "TEST",
+	}
+
+	actions, err := test.Explain(context.TODO(), &common.CLIConfigFlags{Role: "master", DetectIP: "/opt/mesosphere/bin/detect_ip"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"exec /opt/mesosphere/bin/detect_ip - detect the node IP address",
+		"GET http://<node ip>:5050/metrics/snapshot (auth: none) - Mesos metrics snapshot",
+	}
+
+	if len(actions) != len(expected) {
+		t.Fatalf("expect %d actions. Got %v", len(expected), actions)
+	}
+
+	for i, action := range actions {
+		if action.String() != expected[i] {
+			t.Fatalf("expect action %q. Got %q", expected[i], action)
+		}
+	}
+}
+
 // TestMesosMetricsCheckRun checks run
 func TestMesosMetricsCheckRun(t *testing.T) {
 	for _, testCase := range []struct {
@@ -104,7 +131,7 @@ func TestMesosMetricsCheckRun(t *testing.T) {
 
 		test := &mesosMetricsCheck{
 			Name: "TEST",
-			urlFunc: func(client *http.Client, cfg *common.CLIConfigFlags) (*url.URL, error) {
+			urlFunc: func(ctx context.Context, client *http.Client, cfg *common.CLIConfigFlags) (*url.URL, error) {
 				return url.Parse(masterServer.URL)
 			},
 		}
@@ -114,3 +141,24 @@ func TestMesosMetricsCheckRun(t *testing.T) {
 		}
 	}
 }
+
+func TestMesosMetricsCheckFakeCluster(t *testing.T) {
+	cluster := fakecluster.New(fakecluster.UnhealthyUnit())
+	defer cluster.Close()
+
+	for _, testCase := range []struct {
+		ip     string
+		role   string
+		status int
+	}{
+		{"10.0.0.2", "master", constants.StatusOK},
+		{"10.0.1.2", "agent", constants.StatusOK},
+		{"10.0.1.1", "agent", constants.StatusFailure},
+	} {
+		cfg := &common.CLIConfigFlags{NodeIPStr: testCase.ip, Role: testCase.role}
+		_, status, err := newMesosMetricsCheck("TEST").Run(cluster.Context(context.TODO()), cfg)
+		if status != testCase.status {
+			t.Fatalf("node %s: expect status %d. Got %d: %v", testCase.ip, testCase.status, status, err)
+		}
+	}
+}
@@ -1,4 +1,6 @@
+//go:build linux
 // +build linux
+
 // Copyright © 2017 Mesosphere Inc. <http://mesosphere.com>
 //
 // Licensed under the Apache License, Version 2.0 (the "License");
@@ -18,49 +20,167 @@ package time
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/dcos/dcos-checks/common"
 	"github.com/dcos/dcos-checks/constants"
+	"github.com/dcos/dcos-go/dcos"
 	"github.com/pkg/errors"
-	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 const (
-	// STA_UNSYNC is taken from https://github.com/torvalds/linux/blob/master/include/uapi/linux/timex.h#L137
-	staUnsync = 0x0040
+	// default thresholds of the estimated clock error. The warning threshold is disabled by default,
+	// so that a clock which passed the check before does not start to warn.
+	defaultEstErrorWarning = 0
+	defaultEstErrorFailure = 100 * time.Millisecond
 
-	// 100 millisecond
-	maxEstErrorUs = int64(time.Microsecond * 100000)
+	// ntpLink is a link to the time synchronization troubleshooting guide.
+	ntpLink = "https://chrony.tuxfamily.org/faq.html"
 )
 
+// threshold is a warning and a failure threshold of a clock error. A zero threshold is disabled.
+type threshold struct {
+	Warning time.Duration
+	Failure time.Duration
+}
+
+// status returns the status of the absolute clock error d and the exceeded threshold.
+func (th threshold) status(d time.Duration) (int, time.Duration) {
+	if d < 0 {
+		d = -d
+	}
+
+	switch {
+	case th.Failure > 0 && d > th.Failure:
+		return constants.StatusFailure, th.Failure
+	case th.Warning > 0 && d > th.Warning:
+		return constants.StatusWarning, th.Warning
+	}
+	return constants.StatusOK, 0
+}
+
+// perfData returns the clock error d in seconds with the thresholds. Errors which can be negative
+// are alerted outside of the symmetric range.
+func (th threshold) perfData(label string, d time.Duration, signed bool) common.PerfData {
+	data := common.PerfData{
+		Label:    label,
+		Value:    d.Seconds(),
+		UOM:      "s",
+		Warning:  thresholdRange(th.Warning, signed),
+		Critical: thresholdRange(th.Failure, signed),
+	}
+
+	if !signed {
+		data.Min = "0"
+	}
+	return data
+}
+
+// thresholdRange returns a Nagios threshold range of a threshold, or an empty string if disabled.
+func thresholdRange(d time.Duration, signed bool) string {
+	if d == 0 {
+		return ""
+	}
+
+	value := strconv.FormatFloat(d.Seconds(), 'f', -1, 64)
+	if signed {
+		return "-" + value + ":" + value
+	}
+	return value
+}
+
+// validate returns an error if the warning threshold exceeds the failure threshold.
+func (th threshold) validate(name string) error {
+	if th.Warning < 0 || th.Failure < 0 {
+		return errors.Errorf("%s thresholds must not be negative", name)
+	}
+
+	if th.Warning > 0 && th.Failure > 0 && th.Warning > th.Failure {
+		return errors.Errorf("%s warning threshold %s exceeds failure threshold %s", name, th.Warning, th.Failure)
+	}
+	return nil
+}
+
 // timeCheck is a time check structure.
 type timeCheck struct {
 	Name string
 
+	// EstError, MaxError and Offset are the thresholds of the estimated error, the maximum
+	// error and the absolute offset of the clock reported by adjtimex.
+	EstError threshold
+	MaxError threshold
+	Offset   threshold
+
 	runAdjtimex func(*syscall.Timex) (int, error)
 }
 
-// timeCmd represents the time command
-var timeCmd = &cobra.Command{
-	Use:   "time",
-	Short: "Verify time is synced",
-	Long:  `This check uses a system call adjtimex to validate time is synced.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		common.RunCheck(context.TODO(), newTimeCheck("Check clock synchronization"))
-	},
+func init() {
+	common.RegisterCheck(common.CheckSpec{
+		Name:        "time",
+		Description: "Verify time is synced",
+		Long: `This check uses a system call adjtimex to validate time is synced.
+
+The check fails if the clock is in unsync state. The estimated error, the maximum error and
+the offset of the clock are compared to the warning and failure thresholds, a zero threshold
+is disabled. The full adjtimex state is reported along with the result.`,
+		Roles: []string{dcos.RoleMaster, dcos.RoleAgent, dcos.RoleAgentPublic},
+		Tags:  []string{"node", "system"},
+		Flags: addFlags,
+		New:   newCheckFromFlags,
+	})
+}
+
+// addFlags adds the check parameters to the flag set.
+func addFlags(flags *pflag.FlagSet) {
+	flags.Duration("esterror-warning", defaultEstErrorWarning, "Warn if the estimated clock error exceeds the duration")
+	flags.Duration("esterror-failure", defaultEstErrorFailure, "Fail if the estimated clock error exceeds the duration")
+	flags.Duration("maxerror-warning", 0, "Warn if the maximum clock error exceeds the duration")
+	flags.Duration("maxerror-failure", 0, "Fail if the maximum clock error exceeds the duration")
+	flags.Duration("offset-warning", 0, "Warn if the absolute clock offset exceeds the duration")
+	flags.Duration("offset-failure", 0, "Fail if the absolute clock offset exceeds the duration")
 }
 
-// Register adds this command to the root command
-func Register(root *cobra.Command) {
-	root.AddCommand(timeCmd)
+// newCheckFromFlags returns a time check configured with the given flags.
+func newCheckFromFlags(flags *pflag.FlagSet, args []string) (common.DCOSChecker, error) {
+	check := newTimeCheck("Check clock synchronization")
+
+	for _, th := range []struct {
+		name      string
+		threshold *threshold
+	}{
+		{"esterror", &check.EstError},
+		{"maxerror", &check.MaxError},
+		{"offset", &check.Offset},
+	} {
+		var err error
+		if th.threshold.Warning, err = flags.GetDuration(th.name + "-warning"); err != nil {
+			return nil, err
+		}
+
+		if th.threshold.Failure, err = flags.GetDuration(th.name + "-failure"); err != nil {
+			return nil, err
+		}
+
+		if err := th.threshold.validate(th.name); err != nil {
+			return nil, err
+		}
+	}
+	return check, nil
 }
 
-// newTimeCheck returns a new initialized instance of timeCheck.
-func newTimeCheck(name string) common.DCOSChecker {
+// newTimeCheck returns a new initialized instance of timeCheck with the default thresholds.
+func newTimeCheck(name string) *timeCheck {
 	return &timeCheck{
-		Name:        name,
+		Name: name,
+		EstError: threshold{
+			Warning: defaultEstErrorWarning,
+			Failure: defaultEstErrorFailure,
+		},
 		runAdjtimex: syscall.Adjtimex,
 	}
 }
@@ -70,28 +190,121 @@ func (t *timeCheck) ID() string {
 	return t.Name
 }
 
+// Explain returns the system call made by the check.
+func (t *timeCheck) Explain(ctx context.Context, cfg *common.CLIConfigFlags) ([]common.Action, error) {
+	return []common.Action{{
+		Kind:        common.ActionSyscall,
+		Description: "read the kernel clock synchronization state",
+		Syscall:     "adjtimex",
+	}}, nil
+}
+
 // Run executes the check.
 func (t *timeCheck) Run(ctx context.Context, cfg *common.CLIConfigFlags) (string, int, error) {
+	return t.RunDetailed(ctx, cfg).Tuple()
+}
+
+// RunDetailed executes the check and returns a result with the adjtimex state.
+func (t *timeCheck) RunDetailed(ctx context.Context, cfg *common.CLIConfigFlags) common.CheckResult {
 	tBuf := syscall.Timex{}
 
 	// intentionally ignore status. If err != nil, status != 0
-	_, err := t.runAdjtimex(&tBuf)
+	clockState, err := t.runAdjtimex(&tBuf)
 	if err != nil {
-		return "", constants.StatusUnknown, errors.Wrap(err, "unable to make a system call adjtimex")
+		return common.CheckResult{
+			Status: constants.StatusUnknown,
+			Err:    errors.Wrap(err, "unable to make a system call adjtimex"),
+		}
+	}
+
+	state := newTimexState(clockState, &tBuf)
+	result := common.CheckResult{
+		Status:  constants.StatusOK,
+		Details: state.String(),
+		Data:    state.data(),
+		PerfData: []common.PerfData{
+			t.EstError.perfData("esterror", state.EstError, false),
+			t.MaxError.perfData("maxerror", state.MaxError, false),
+			t.Offset.perfData("offset", state.Offset, true),
+		},
+	}
+
+	type problem struct {
+		status int
+		msg    string
+	}
+
+	var problems []problem
+	addProblem := func(status int, msg string, remediation common.Remediation) {
+		if status > result.Status {
+			result.Status = status
+		}
+		problems = append(problems, problem{status: status, msg: msg})
+
+		for _, r := range result.Remediation {
+			if r == remediation {
+				return
+			}
+		}
+		result.Remediation = append(result.Remediation, remediation)
+	}
+
+	stability := common.Remediation{
+		Hint:    "Make sure the NTP daemon (chronyd or ntpd) is running and reaches stable time sources",
+		Link:    ntpLink,
+		Command: "chronyc tracking || ntpq -p",
 	}
 
 	// This is to check if NTP thinks the clock is unstable
-	if diff := tBuf.Esterror - maxEstErrorUs; diff > 0 {
-		return fmt.Sprintf("Clock is less stable than allowed. Max estimated error exceeded by: %s", time.Duration(diff)*time.Microsecond), constants.StatusFailure, nil
+	switch status, limit := t.EstError.status(state.EstError); status {
+	case constants.StatusFailure:
+		addProblem(status, fmt.Sprintf("Clock is less stable than allowed. Max estimated error exceeded by: %s",
+			state.EstError-limit), stability)
+	case constants.StatusWarning:
+		addProblem(status, fmt.Sprintf("Clock is less stable than expected. Estimated error warning threshold exceeded by: %s",
+			state.EstError-limit), stability)
+	}
+
+	if status, limit := t.MaxError.status(state.MaxError); status != constants.StatusOK {
+		addProblem(status, fmt.Sprintf("Clock maximum error %s exceeds %s", state.MaxError, limit), stability)
+	}
+
+	if status, limit := t.Offset.status(state.Offset); status != constants.StatusOK {
+		addProblem(status, fmt.Sprintf("Clock offset %s exceeds %s", state.Offset, limit), common.Remediation{
+			Hint:    "Step the clock to the time of the NTP sources and make sure the NTP daemon keeps it synchronized",
+			Link:    ntpLink,
+			Command: "chronyc makestep",
+		})
 	}
 
 	// If NTP is down for ~16000 seconds, the clock will go unsync, based on
 	// modern kernels. Unfortunately, even though there are a bunch of other
 	// heuristics in the timex struct, it doesn't make a ton of sense to look
 	// at them. Maybe in the future we can do something smarter.
-	if (tBuf.Status & staUnsync) > 0 {
-		return "Clock is out of sync / in unsync state. Must be synchronized for proper operation.", constants.StatusFailure, nil
+	if state.unsync {
+		addProblem(constants.StatusFailure, "Clock is out of sync / in unsync state. Must be synchronized for proper operation.",
+			common.Remediation{
+				Hint:    "Make sure the NTP daemon (chronyd or ntpd) is enabled, running and synchronized",
+				Link:    ntpLink,
+				Command: "timedatectl status",
+			})
+	}
+
+	result.Summary = "Clock is synced"
+	if len(problems) == 0 {
+		return result
 	}
 
-	return "Clock is synced", constants.StatusOK, nil
+	// the worst problem is the summary.
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].status > problems[j].status
+	})
+
+	result.Summary = problems[0].msg
+	details := []string{}
+	for _, p := range problems[1:] {
+		details = append(details, p.msg)
+	}
+	result.Details = strings.Join(append(details, result.Details), "\n")
+	return result
 }
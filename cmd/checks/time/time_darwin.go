@@ -1,4 +1,6 @@
+//go:build darwin
 // +build darwin
+
 // Copyright © 2017 Mesosphere Inc. <http://mesosphere.com>
 //
 // Licensed under the Apache License, Version 2.0 (the "License");
@@ -15,9 +17,4 @@
 
 package time
 
-import "github.com/spf13/cobra"
-
-// Add doesn't add this check on darwin b/c it's not supported
-func Add(root *cobra.Command) {
-	// does nothing on darwin
-}
+// the time check relies on adjtimex system call and is not registered on darwin.
@@ -1,3 +1,4 @@
+//go:build linux
 // +build linux
 
 package time
@@ -6,9 +7,11 @@ import (
 	"context"
 	"syscall"
 	"testing"
+	"time"
 
 	"github.com/dcos/dcos-checks/constants"
 	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
 )
 
 func TestTimeCheckBadStatus(t *testing.T) {
@@ -17,47 +20,49 @@ func TestTimeCheckBadStatus(t *testing.T) {
 		return 0, nil
 	}
 
-	check := &timeCheck{
-		runAdjtimex: mockrunAdjtimex,
-	}
+	check := newTimeCheck("TEST")
+	check.runAdjtimex = mockrunAdjtimex
 
-	msg, code, err := check.Run(context.TODO(), nil)
-	if err != nil {
-		t.Fatal(err)
+	result := check.RunDetailed(context.TODO(), nil)
+	if result.Err != nil {
+		t.Fatal(result.Err)
 	}
 
-	if code != constants.StatusFailure {
-		t.Fatalf("expect status %d. Got %d", constants.StatusFailure, code)
+	if result.Status != constants.StatusFailure {
+		t.Fatalf("expect status %d. Got %d", constants.StatusFailure, result.Status)
 	}
 
 	expectedMsg := "Clock is out of sync / in unsync state. Must be synchronized for proper operation."
-	if msg != expectedMsg {
-		t.Fatalf("expect %s. Got %s", expectedMsg, msg)
+	if result.Summary != expectedMsg {
+		t.Fatalf("expect %s. Got %s", expectedMsg, result.Summary)
+	}
+
+	if remediation := result.Remediation; len(remediation) != 1 || remediation[0].Command != "timedatectl status" {
+		t.Fatalf("expect NTP remediation. Got %v", remediation)
 	}
 }
 
 func TestTimeCheckClockStable(t *testing.T) {
 	mockrunAdjtimex := func(t *syscall.Timex) (int, error) {
-		t.Esterror = maxEstErrorUs + 1000
+		t.Esterror = int64((defaultEstErrorFailure + time.Millisecond) / time.Microsecond)
 		return 0, nil
 	}
 
-	check := &timeCheck{
-		runAdjtimex: mockrunAdjtimex,
-	}
+	check := newTimeCheck("TEST")
+	check.runAdjtimex = mockrunAdjtimex
 
-	msg, code, err := check.Run(context.TODO(), nil)
-	if err != nil {
-		t.Fatal(err)
+	result := check.RunDetailed(context.TODO(), nil)
+	if result.Err != nil {
+		t.Fatal(result.Err)
 	}
 
-	if code != constants.StatusFailure {
-		t.Fatalf("expect status %d. Got %d", constants.StatusFailure, code)
+	if result.Status != constants.StatusFailure {
+		t.Fatalf("expect status %d. Got %d", constants.StatusFailure, result.Status)
 	}
 
 	expectedMsg := "Clock is less stable than allowed. Max estimated error exceeded by: 1ms"
-	if msg != expectedMsg {
-		t.Fatalf("expect %s. Got %s", expectedMsg, msg)
+	if result.Summary != expectedMsg {
+		t.Fatalf("expect %s. Got %s", expectedMsg, result.Summary)
 	}
 }
 
@@ -66,9 +71,8 @@ func TestTimeCheckError(t *testing.T) {
 		return 1, errors.New("error")
 	}
 
-	check := &timeCheck{
-		runAdjtimex: mockrunAdjtimex,
-	}
+	check := newTimeCheck("TEST")
+	check.runAdjtimex = mockrunAdjtimex
 
 	_, _, err := check.Run(context.TODO(), nil)
 	if err == nil {
@@ -81,9 +85,8 @@ func TestTimeCheck(t *testing.T) {
 		return 0, nil
 	}
 
-	check := &timeCheck{
-		runAdjtimex: mockrunAdjtimex,
-	}
+	check := newTimeCheck("TEST")
+	check.runAdjtimex = mockrunAdjtimex
 
 	msg, code, err := check.Run(context.TODO(), nil)
 	if err != nil {
@@ -94,8 +97,145 @@ func TestTimeCheck(t *testing.T) {
 		t.Fatalf("expect code %d. Got %d", constants.StatusOK, code)
 	}
 
-	expectedMsg := "Clock is synced"
+	expectedMsg := "Clock is synced\n" +
+		"adjtimex: state TIME_OK, offset 0s, frequency 0.000 ppm, maxerror 0s, esterror 0s, status none, TAI offset 0s"
 	if msg != expectedMsg {
 		t.Fatalf("expect msg %s. Got %s", expectedMsg, msg)
 	}
 }
+
+func TestTimeCheckPerfData(t *testing.T) {
+	mockrunAdjtimex := func(t *syscall.Timex) (int, error) {
+		t.Esterror = 1500
+		t.Offset = -2000
+		return 0, nil
+	}
+
+	check := newTimeCheck("TEST")
+	check.runAdjtimex = mockrunAdjtimex
+	check.Offset = threshold{Warning: 100 * time.Millisecond}
+
+	result := check.RunDetailed(context.TODO(), nil)
+	if result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	expected := []string{"esterror=0.0015s;;0.1;0", "maxerror=0s;;;0", "offset=-0.002s;-0.1:0.1"}
+	if len(result.PerfData) != len(expected) {
+		t.Fatalf("expect perf data %v. Got %+v", expected, result.PerfData)
+	}
+
+	for i, data := range result.PerfData {
+		if data.String() != expected[i] {
+			t.Fatalf("expect %s. Got %s", expected[i], data)
+		}
+	}
+}
+
+func TestTimeCheckThresholds(t *testing.T) {
+	for _, testCase := range []struct {
+		name     string
+		args     []string
+		timex    syscall.Timex
+		status   int
+		expected string
+	}{
+		{
+			name:     "esterror warning",
+			args:     []string{"--esterror-warning", "50ms"},
+			timex:    syscall.Timex{Esterror: 60000},
+			status:   constants.StatusWarning,
+			expected: "Clock is less stable than expected. Estimated error warning threshold exceeded by: 10ms",
+		},
+		{
+			name:     "esterror warning disabled by default",
+			timex:    syscall.Timex{Esterror: 60000},
+			status:   constants.StatusOK,
+			expected: "Clock is synced",
+		},
+		{
+			name:     "maxerror failure",
+			args:     []string{"--maxerror-warning", "500ms", "--maxerror-failure", "1s"},
+			timex:    syscall.Timex{Maxerror: 1500000},
+			status:   constants.StatusFailure,
+			expected: "Clock maximum error 1.5s exceeds 1s",
+		},
+		{
+			name:     "nanosecond offset warning",
+			args:     []string{"--offset-warning", "1ms"},
+			timex:    syscall.Timex{Offset: -2000000, Status: staNano | staPLL},
+			status:   constants.StatusWarning,
+			expected: "Clock offset -2ms exceeds 1ms",
+		},
+		{
+			name:     "worst problem first",
+			args:     []string{"--offset-warning", "1ms"},
+			timex:    syscall.Timex{Offset: 5000, Status: staUnsync},
+			status:   constants.StatusFailure,
+			expected: "Clock is out of sync / in unsync state. Must be synchronized for proper operation.",
+		},
+		{
+			name:     "disabled thresholds",
+			args:     []string{"--esterror-warning", "0", "--esterror-failure", "0"},
+			timex:    syscall.Timex{Esterror: 16000000},
+			status:   constants.StatusOK,
+			expected: "Clock is synced",
+		},
+	} {
+		flags := pflag.NewFlagSet("time", pflag.ContinueOnError)
+		addFlags(flags)
+		if err := flags.Parse(testCase.args); err != nil {
+			t.Fatal(err)
+		}
+
+		check, err := newCheckFromFlags(flags, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		timex := testCase.timex
+		check.(*timeCheck).runAdjtimex = func(t *syscall.Timex) (int, error) {
+			*t = timex
+			return 0, nil
+		}
+
+		result := check.(*timeCheck).RunDetailed(context.TODO(), nil)
+		if result.Status != testCase.status || result.Summary != testCase.expected {
+			t.Fatalf("%s: expect status %d and summary %q. Got %d: %q", testCase.name, testCase.status,
+				testCase.expected, result.Status, result.Summary)
+		}
+	}
+}
+
+func TestTimeCheckInvalidThresholds(t *testing.T) {
+	flags := pflag.NewFlagSet("time", pflag.ContinueOnError)
+	addFlags(flags)
+	if err := flags.Parse([]string{"--offset-warning", "2s", "--offset-failure", "1s"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := newCheckFromFlags(flags, nil); err == nil {
+		t.Fatal("expect an error if the warning threshold exceeds the failure threshold")
+	}
+}
+
+func TestTimexState(t *testing.T) {
+	state := newTimexState(1, &syscall.Timex{
+		Offset:   -250,
+		Freq:     65536 * 3 / 2,
+		Maxerror: 20000,
+		Esterror: 400,
+		Status:   staPLL | staIns,
+		Tai:      37,
+	})
+
+	expected := "adjtimex: state TIME_INS, offset -250µs, frequency 1.500 ppm, maxerror 20ms, esterror 400µs, " +
+		"status STA_PLL|STA_INS, TAI offset 37s"
+	if state.String() != expected {
+		t.Fatalf("expect %s. Got %s", expected, state)
+	}
+
+	if state.unsync {
+		t.Fatal("expect the clock to be synchronized")
+	}
+}
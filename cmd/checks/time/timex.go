@@ -0,0 +1,133 @@
+//go:build linux
+// +build linux
+
+package time
+
+import (
+	"fmt"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// adjtimex status bits taken from https://github.com/torvalds/linux/blob/master/include/uapi/linux/timex.h
+const (
+	staPLL       = 0x0001
+	staPPSFreq   = 0x0002
+	staPPSTime   = 0x0004
+	staFLL       = 0x0008
+	staIns       = 0x0010
+	staDel       = 0x0020
+	staUnsync    = 0x0040
+	staFreqHold  = 0x0080
+	staPPSSignal = 0x0100
+	staPPSJitter = 0x0200
+	staPPSWander = 0x0400
+	staPPSError  = 0x0800
+	staClockErr  = 0x1000
+	staNano      = 0x2000
+	staMode      = 0x4000
+	staClk       = 0x8000
+)
+
+// statusBits are the names of the adjtimex status bits.
+var statusBits = []struct {
+	bit  int64
+	name string
+}{
+	{staPLL, "STA_PLL"},
+	{staPPSFreq, "STA_PPSFREQ"},
+	{staPPSTime, "STA_PPSTIME"},
+	{staFLL, "STA_FLL"},
+	{staIns, "STA_INS"},
+	{staDel, "STA_DEL"},
+	{staUnsync, "STA_UNSYNC"},
+	{staFreqHold, "STA_FREQHOLD"},
+	{staPPSSignal, "STA_PPSSIGNAL"},
+	{staPPSJitter, "STA_PPSJITTER"},
+	{staPPSWander, "STA_PPSWANDER"},
+	{staPPSError, "STA_PPSERROR"},
+	{staClockErr, "STA_CLOCKERR"},
+	{staNano, "STA_NANO"},
+	{staMode, "STA_MODE"},
+	{staClk, "STA_CLK"},
+}
+
+// clockStates are the names of the clock states returned by adjtimex.
+var clockStates = []string{"TIME_OK", "TIME_INS", "TIME_DEL", "TIME_OOP", "TIME_WAIT", "TIME_ERROR"}
+
+// timexState is the kernel clock synchronization state reported by adjtimex.
+type timexState struct {
+	// State is the clock state returned by adjtimex, e.g. TIME_OK.
+	State string
+
+	// Status are the names of the status bits set, e.g. STA_PLL.
+	Status []string
+
+	Offset   time.Duration
+	MaxError time.Duration
+	EstError time.Duration
+
+	// Frequency is the frequency offset in ppm.
+	Frequency float64
+
+	// TAI is the offset between TAI and UTC in seconds.
+	TAI int64
+
+	unsync bool
+}
+
+// newTimexState returns the state described by the adjtimex clock state and buffer.
+func newTimexState(state int, buf *syscall.Timex) timexState {
+	s := timexState{
+		State:    fmt.Sprintf("%d", state),
+		MaxError: time.Duration(buf.Maxerror) * time.Microsecond,
+		EstError: time.Duration(buf.Esterror) * time.Microsecond,
+
+		// the frequency is in ppm with a 16-bit fractional part.
+		Frequency: float64(buf.Freq) / 65536,
+		TAI:       int64(buf.Tai),
+		unsync:    int64(buf.Status)&staUnsync > 0,
+	}
+
+	if state >= 0 && state < len(clockStates) {
+		s.State = clockStates[state]
+	}
+
+	// the offset is in nanoseconds if STA_NANO is set and in microseconds otherwise.
+	s.Offset = time.Duration(buf.Offset) * time.Microsecond
+	if int64(buf.Status)&staNano > 0 {
+		s.Offset = time.Duration(buf.Offset)
+	}
+
+	for _, b := range statusBits {
+		if int64(buf.Status)&b.bit > 0 {
+			s.Status = append(s.Status, b.name)
+		}
+	}
+	return s
+}
+
+// String returns a one line description of the state.
+func (s timexState) String() string {
+	status := strings.Join(s.Status, "|")
+	if status == "" {
+		status = "none"
+	}
+
+	return fmt.Sprintf("adjtimex: state %s, offset %s, frequency %.3f ppm, maxerror %s, esterror %s, status %s, TAI offset %ds",
+		s.State, s.Offset, s.Frequency, s.MaxError, s.EstError, status, s.TAI)
+}
+
+// data returns the state as structured check data.
+func (s timexState) data() map[string]interface{} {
+	return map[string]interface{}{
+		"state":            s.State,
+		"status":           s.Status,
+		"offset_seconds":   s.Offset.Seconds(),
+		"frequency_ppm":    s.Frequency,
+		"maxerror_seconds": s.MaxError.Seconds(),
+		"esterror_seconds": s.EstError.Seconds(),
+		"tai_offset":       s.TAI,
+	}
+}
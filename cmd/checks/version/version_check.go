@@ -17,39 +17,103 @@ package version
 import (
 	"context"
 	"encoding/json"
+	"net/http"
+	"time"
 
 	"github.com/dcos/dcos-checks/common"
 	"github.com/dcos/dcos-checks/constants"
+	"github.com/dcos/dcos-go/dcos"
 	"github.com/pkg/errors"
-	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// Policies of unreachable nodes.
+const (
+	unreachableWarn   = "warn"
+	unreachableFail   = "fail"
+	unreachableIgnore = "ignore"
 )
 
 // versionCheck struct
 type versionCheck struct {
 	Name          string
 	ClusterLeader string
+
+	// ExpectedVersion fails the check on any node not at this DC/OS version if set.
+	ExpectedVersion string
+
+	// Unreachable is a policy of unreachable nodes, warn, fail or ignore, applied if more
+	// than MaxUnreachable nodes are unreachable.
+	Unreachable    string
+	MaxUnreachable int
 }
 
-// versionCmd represents the version command
-var versionCmd = &cobra.Command{
-	Use:   "version",
-	Short: "Check DC/OS version of the cluster",
-	Long: `Check dc/os version on each node in the cluster.
-At any point there shouldnt be more than 2 versions that exist.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		common.RunCheck(context.TODO(), newVersionCheck("DC/OS version check"))
-	},
+func init() {
+	common.RegisterCheck(common.CheckSpec{
+		Name:        "version",
+		Description: "Check DC/OS version of the cluster",
+		Long: `Check dc/os version on each node in the cluster.
+At any point there shouldnt be more than 2 versions that exist.
+
+The version, image commit and bootstrap ID of every master and agent is reported grouped by
+version. Nodes running the same version must share the same bootstrap ID. With --expected-version
+the check fails on any node not at the expected version.
+
+The nodes are queried concurrently by at most --cluster-workers workers. Unreachable nodes are
+reported separately and make the check warn, fail or are ignored according to --unreachable once
+more than --max-unreachable nodes are unreachable. Reachable nodes which do not return a valid
+version fail the check. Agents recovered by the leader after a failover which have
+not re-registered yet are queried as well.`,
+		Roles:         []string{dcos.RoleMaster},
+		Tags:          []string{"cluster", "http"},
+		Requires:      []string{"ip"},
+		Timeout:       time.Minute,
+		ClusterAccess: true,
+		Flags:         addFlags,
+		New:           newCheckFromFlags,
+	})
 }
 
-// Register adds this command to the root command
-func Register(root *cobra.Command) {
-	root.AddCommand(versionCmd)
+// addFlags adds the check parameters to the flag set.
+func addFlags(flags *pflag.FlagSet) {
+	flags.String("expected-version", "", "Fail on any node not at the given DC/OS version")
+	flags.String("unreachable", unreachableWarn, "Policy of unreachable nodes (valid policies: warn, fail, ignore)")
+	flags.Int("max-unreachable", 0, "Number of unreachable nodes tolerated before the unreachable policy applies")
+}
+
+// newCheckFromFlags returns a version check configured with the given flags.
+func newCheckFromFlags(flags *pflag.FlagSet, args []string) (common.DCOSChecker, error) {
+	check := newVersionCheck("DC/OS version check")
+
+	var err error
+	if check.ExpectedVersion, err = flags.GetString("expected-version"); err != nil {
+		return nil, err
+	}
+
+	if check.Unreachable, err = flags.GetString("unreachable"); err != nil {
+		return nil, err
+	}
+
+	if check.MaxUnreachable, err = flags.GetInt("max-unreachable"); err != nil {
+		return nil, err
+	}
+
+	switch check.Unreachable {
+	case unreachableWarn, unreachableFail, unreachableIgnore:
+	default:
+		return nil, errors.Errorf("invalid unreachable policy %s, valid policies: %s, %s, %s", check.Unreachable,
+			unreachableWarn, unreachableFail, unreachableIgnore)
+	}
+	return check, nil
 }
 
 // newVersionCheck returns an initialized instance of *versionCheck.
 func newVersionCheck(name string) *versionCheck {
-	check := &versionCheck{Name: name}
-	check.ClusterLeader = "leader.mesos"
+	check := &versionCheck{
+		Name:        name,
+		Unreachable: unreachableWarn,
+	}
+	check.ClusterLeader = dcos.DNSRecordLeader
 	return check
 }
 
@@ -60,120 +124,157 @@ func (vc *versionCheck) ID() string {
 
 // Run is running
 func (vc *versionCheck) Run(ctx context.Context, cfg *common.CLIConfigFlags) (string, int, error) {
+	return vc.RunDetailed(ctx, cfg).Tuple()
+}
 
-	// List of masters
-	var masterOpt common.URLFields
-	masterOpt.Host = vc.ClusterLeader
-	masterOpt.Port = constants.MesosDNSPort
-	masterOpt.Path = "/v1/hosts/master.mesos"
-
-	masterList, err := vc.ListOfMasters(cfg, masterOpt)
+// RunDetailed returns a result with an item for each node and the version matrix of the cluster.
+func (vc *versionCheck) RunDetailed(ctx context.Context, cfg *common.CLIConfigFlags) common.CheckResult {
+	// the nodes are discovered once per run and shared with the other checks of the run.
+	clusterNodes, err := common.ClusterNodes(ctx, cfg)
 	if err != nil {
-		return "", constants.StatusFailure, err
+		return common.CheckResult{Status: constants.StatusFailure, Err: err}
 	}
 
-	// List of agents
-	var agentOpt common.URLFields
-	agentOpt.Host = vc.ClusterLeader
-	agentOpt.Port = constants.MesosMasterHTTPPort
-	agentOpt.Path = "/slaves"
-
-	agentList, err := vc.ListOfAgents(cfg, agentOpt)
-	if err != nil {
-		return "", constants.StatusFailure, err
-	}
-
-	// Check version endpoint for each endpoint
-	var version map[string]bool
-	version = make(map[string]bool)
-	var versionURL common.URLFields
-	versionURL.Path = "/dcos-metadata/dcos-version.json"
-	for _, master := range masterList {
-		versionURL.Host = master
-		versionURL.Port = 0
-		if cfg.ForceTLS {
-			versionURL.Port = constants.AdminrouterMasterHTTPSPort
+	nodes, failed, unreachable := vc.queryVersions(ctx, cfg, clusterNodes)
+	if len(nodes) == 0 && len(failed)+len(unreachable) > 0 {
+		errs := append(failed, unreachable...)
+		return common.CheckResult{
+			Status: constants.StatusUnknown,
+			Err:    errors.Errorf("Unable to get version of any of %d nodes: %s", len(errs), errs[0].Error),
 		}
-		ver, err := vc.GetVersion(cfg, versionURL)
-		if err != nil {
-			return "", constants.StatusFailure, errors.Wrap(err, "Unable to get version")
-		}
-		version[ver] = true
 	}
 
-	for _, agent := range agentList {
-		versionURL.Host = agent
-		versionURL.Port = constants.AdminrouterAgentHTTPPort
-		if cfg.ForceTLS {
-			versionURL.Port = constants.AdminrouterAgentHTTPSPort
-		}
-		ver, err := vc.GetVersion(cfg, versionURL)
-		if err != nil {
-			return "", constants.StatusFailure, errors.Wrap(err, "Unable to get version")
-		}
-		version[ver] = true
-	}
+	report := newVersionReport(nodes, failed, unreachable)
+	return report.result(vc.ExpectedVersion, unreachablePolicy{Action: vc.Unreachable, Max: vc.MaxUnreachable})
+}
 
-	if len(version) <= 2 {
-		return "", constants.StatusOK, nil
-	}
+// queryVersions returns the versions of the nodes, the reachable nodes which did not return a valid
+// version and the unreachable nodes, in the same order as the given nodes. At most cfg.ClusterWorkers
+// nodes are queried concurrently.
+func (vc *versionCheck) queryVersions(ctx context.Context, cfg *common.CLIConfigFlags,
+	nodes []common.Node) ([]nodeVersion, []nodeError, []nodeError) {
+	responses := make([]*versionResponse, len(nodes))
+	errs := make([]error, len(nodes))
+	common.ForEachNode(nodes, cfg.ClusterWorkers, func(i int) {
+		url := versionURL(cfg, nodes[i].IP, nodes[i].Role == dcos.RoleMaster)
+		responses[i], errs[i] = vc.getVersionResponse(ctx, cfg, url)
+	})
 
-	if len(version) > 2 {
-		return "", constants.StatusWarning, errors.New("More than 2 dc/os versions on the cluster")
-	}
+	var (
+		versions    []nodeVersion
+		failed      []nodeError
+		unreachable []nodeError
+	)
+	for i, node := range nodes {
+		if errs[i] != nil {
+			nodeErr := nodeError{
+				Host:      node.IP,
+				Role:      node.Role,
+				Recovered: node.Recovered,
+				Error:     errs[i].Error(),
+			}
 
-	return "", constants.StatusUnknown, nil
+			if _, ok := errs[i].(unreachableError); ok {
+				unreachable = append(unreachable, nodeErr)
+			} else {
+				failed = append(failed, nodeErr)
+			}
+			continue
+		}
+
+		versions = append(versions, nodeVersion{
+			Host:        node.IP,
+			Role:        node.Role,
+			Recovered:   node.Recovered,
+			Version:     responses[i].Version,
+			ImageCommit: responses[i].DcosImageCommit,
+			BootstrapID: responses[i].BootstrapID,
+		})
+	}
+	return versions, failed, unreachable
 }
 
-// ListOfMasters returns the current list of masters in the cluster
-func (vc *versionCheck) ListOfMasters(cfg *common.CLIConfigFlags, urlopt common.URLFields) ([]string, error) {
-	var masterResponse masterListResponses
-	var masterIPs []string
-	_, response, err := common.HTTPRequest(cfg, urlopt)
-	if err != nil {
-		return nil, errors.Wrap(err, "Unable to fetch list of masters")
+// Explain returns the requests made by the check. The version of every master and agent
+// discovered from the leader is requested.
+func (vc *versionCheck) Explain(ctx context.Context, cfg *common.CLIConfigFlags) ([]common.Action, error) {
+	requests := []struct {
+		url         common.URLFields
+		description string
+	}{
+		{vc.mastersURL(), "list masters"},
+		{vc.agentsURL(), "list agents"},
+		{versionURL(cfg, "<master ip>", true), "DC/OS version of every master"},
+		{versionURL(cfg, "<agent ip>", false), "DC/OS version of every agent"},
 	}
 
-	if err := json.Unmarshal(response, &masterResponse); err != nil {
-		return nil, errors.Wrap(err, "Unable to unmarshal response")
+	var actions []common.Action
+	for _, request := range requests {
+		action, err := common.ExplainHTTPRequest(cfg, request.url, request.description)
+		if err != nil {
+			return actions, err
+		}
+		actions = append(actions, action)
 	}
+	return actions, nil
+}
 
-	for _, addr := range masterResponse {
-		masterIPs = append(masterIPs, addr.IP)
+// mastersURL returns the Mesos DNS endpoint listing the masters.
+func (vc *versionCheck) mastersURL() common.URLFields {
+	return common.URLFields{
+		Host: vc.ClusterLeader,
+		Port: constants.MesosDNSPort,
+		Path: "/v1/hosts/master.mesos",
 	}
-	return masterIPs, nil
 }
 
-// ListOfAgents returns the current list of agents in the cluster
-func (vc *versionCheck) ListOfAgents(cfg *common.CLIConfigFlags, urlopt common.URLFields) ([]string, error) {
-	var agentResponse agentListResponse
-	var agentIPs []string
-	_, response, err := common.HTTPRequest(cfg, urlopt)
-	if err != nil {
-		return nil, errors.Wrap(err, "Unable to fetch list of agents")
+// agentsURL returns the leading Mesos master endpoint listing the agents.
+func (vc *versionCheck) agentsURL() common.URLFields {
+	return common.URLFields{
+		Host: vc.ClusterLeader,
+		Port: constants.MesosMasterHTTPPort,
+		Path: "/slaves",
 	}
+}
 
-	if err := json.Unmarshal(response, &agentResponse); err != nil {
-		return nil, errors.Wrap(err, "Unable to unmarshal response")
+// versionURL returns the DC/OS version endpoint of a master or an agent served by adminrouter.
+func versionURL(cfg *common.CLIConfigFlags, host string, master bool) common.URLFields {
+	urlOpt := common.URLFields{
+		Host: host,
+		Path: "/dcos-metadata/dcos-version.json",
 	}
 
-	for _, hosts := range agentResponse.Slaves {
-		agentIPs = append(agentIPs, hosts.Hostname)
+	switch {
+	case master && cfg.ForceTLS:
+		urlOpt.Port = constants.AdminrouterMasterHTTPSPort
+	case !master && cfg.ForceTLS:
+		urlOpt.Port = constants.AdminrouterAgentHTTPSPort
+	case !master:
+		urlOpt.Port = constants.AdminrouterAgentHTTPPort
 	}
-	return agentIPs, nil
+	return urlOpt
+}
+
+// unreachableError is returned by getVersionResponse if the request to a node failed, as opposed to
+// a node returning an error status or an invalid response.
+type unreachableError struct {
+	error
 }
 
-// GetVersion returns the dc/os version of a node
-func (vc *versionCheck) GetVersion(cfg *common.CLIConfigFlags, urlopt common.URLFields) (string, error) {
+// getVersionResponse returns the dc/os version, image commit and bootstrap ID of a node.
+func (vc *versionCheck) getVersionResponse(ctx context.Context, cfg *common.CLIConfigFlags, urlopt common.URLFields) (*versionResponse, error) {
 	var verResponse versionResponse
-	_, response, err := common.HTTPRequest(cfg, urlopt)
+	status, response, err := common.HTTPRequest(ctx, cfg, urlopt)
 	if err != nil {
-		return "", errors.Wrap(err, "Unable to get version")
+		return nil, unreachableError{errors.Wrap(err, "Unable to get version")}
+	}
+
+	if status != http.StatusOK {
+		return nil, errors.Errorf("Unable to get version: unexpected response status %d", status)
 	}
 
 	if err := json.Unmarshal(response, &verResponse); err != nil {
-		return "", errors.Wrap(err, "Unable to marshal response")
+		return nil, errors.Wrap(err, "Unable to marshal response")
 	}
 
-	return verResponse.Version, nil
+	return &verResponse, nil
 }
@@ -1,14 +1,18 @@
 package version
 
 import (
+	"context"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/dcos/dcos-checks/common"
 	"github.com/dcos/dcos-checks/constants"
+	"github.com/dcos/dcos-checks/fakecluster"
+	"github.com/spf13/pflag"
 )
 
 func TestVersionCheckUrl(t *testing.T) {
@@ -52,144 +56,280 @@ func TestVersionCheckUrl(t *testing.T) {
 	}
 }
 
-// TestVersionCheckListofmasters gets list of masters from mesos-dns endpoint
-func TestVersionCheckListOfMasters(t *testing.T) {
+// TestVersionCheckGetVersionResponse gets the version of a node from /dcos-metadata/dcos-version.json
+func TestVersionCheckGetVersionResponse(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/dcos-metadata/dcos-version.json" {
+			io.WriteString(w, `{"version": "1.10-dev", "dcos-image-commit": "ccb53df0da261508249570df577c47bbbcc09f82", "bootstrap-id": "8468e43583e21ccb482ff303ed7496f84bbadb4d"}`)
+		}
+	})
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	testurl, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("could not parse")
+	}
+
+	test := &versionCheck{Name: "TEST"}
+	urlopt := common.URLFields{Host: testurl.Host, Path: "/dcos-metadata/dcos-version.json"}
+	version, err := test.getVersionResponse(context.TODO(), &common.CLIConfigFlags{}, urlopt)
+	if err != nil {
+		t.Fatalf("Status %s", err)
+	}
+
+	if version.Version != "1.10-dev" || version.BootstrapID != "8468e43583e21ccb482ff303ed7496f84bbadb4d" {
+		t.Fatalf("Getting nonsense %+v, not the correct value", version)
+	}
+}
+
+func TestVersionCheckFakeCluster(t *testing.T) {
 	for _, testCase := range []struct {
-		role      string
-		forceTLS  bool
-		status    int
-		response  string
-		expStatus int
-		expValue  string
+		scenario *fakecluster.Scenario
+		status   int
 	}{
-		{
-			role:      "master",
-			forceTLS:  false,
-			status:    http.StatusOK,
-			response:  `[{ "host": "leader.mesos.", "ip": "10.0.4.197" }]`,
-			expStatus: constants.StatusOK,
-			expValue:  "10.0.4.197",
-		},
+		{fakecluster.Healthy(), constants.StatusOK},
+		{fakecluster.MixedVersions(), constants.StatusWarning},
+		{fakecluster.InconsistentBootstrap(), constants.StatusFailure},
+		{fakecluster.Leaderless(), constants.StatusFailure},
 	} {
-		mockCLICfg := &common.CLIConfigFlags{
-			NodeIPStr: "127.0.0.1",
-			Role:      testCase.role,
-			ForceTLS:  testCase.forceTLS,
-		}
+		cluster := fakecluster.New(testCase.scenario)
+		check := newVersionCheck("TEST")
 
-		masterHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.WriteHeader(testCase.status)
-			w.Header().Set("Content-Type", "application/json")
-			if r.URL.Path == "/v1/hosts/master.mesos" {
-				io.WriteString(w, testCase.response)
-			}
-		})
+		result := check.RunDetailed(cluster.Context(context.TODO()), &common.CLIConfigFlags{Role: "master"})
+		cluster.Close()
 
-		masterServer := httptest.NewServer(masterHandler)
-		defer masterServer.Close()
+		if result.Status != testCase.status {
+			t.Fatalf("scenario %s: expect status %d. Got %d: %s %v", testCase.scenario.Name, testCase.status,
+				result.Status, result.Summary, result.Err)
+		}
 
-		test := &versionCheck{
-			Name:          "TEST",
-			ClusterLeader: "127.0.0.1",
+		if testCase.status == constants.StatusWarning && result.PerfData[0].Value != 3 {
+			t.Fatalf("expect 3 distinct versions. Got %+v", result.PerfData)
 		}
 
-		testurl, err := url.Parse(masterServer.URL)
-		if err != nil {
-			t.Fatalf("could not parse")
+		if result.Err == nil && len(result.Items) != len(testCase.scenario.Nodes) {
+			t.Fatalf("expect an item for each node. Got %+v", result.Items)
 		}
-		var masterurlopt common.URLFields
-		masterurlopt.Host = testurl.Host
-		masterurlopt.Port = 0
-		masterurlopt.Path = "/v1/hosts/master.mesos"
+	}
+}
 
-		masters, err := test.ListOfMasters(mockCLICfg, masterurlopt)
+func TestVersionCheckMatrix(t *testing.T) {
+	cluster := fakecluster.New(fakecluster.MixedVersions())
+	defer cluster.Close()
 
-		if err != nil {
-			t.Fatalf("Status %s", err)
-		}
-		if masters[0] != testCase.expValue {
-			t.Fatalf("Getting random nonsense, not the correct value")
-		}
+	check := newVersionCheck("TEST")
+	result := check.RunDetailed(cluster.Context(context.TODO()), &common.CLIConfigFlags{Role: "master"})
+	if result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	expected := `More than 2 DC/OS versions on the cluster: 1.9.4, 1.10.0, 1.11.0
+1.9.4:
+  10.0.1.1 (agent): image commit 1a2b3c4d5e6f7a8b, bootstrap ID 8b7a6f5e4d3c2b1a
+1.10.0:
+  10.0.0.1 (master): image commit 0123456789abcdef, bootstrap ID fedcba9876543210
+  10.0.0.2 (master): image commit 0123456789abcdef, bootstrap ID fedcba9876543210
+  10.0.0.3 (master): image commit 0123456789abcdef, bootstrap ID fedcba9876543210
+  10.0.1.2 (agent): image commit 0123456789abcdef, bootstrap ID fedcba9876543210
+1.11.0:
+  10.0.2.1 (agent_public): image commit 9f8e7d6c5b4a3928, bootstrap ID 2839a4b5c6d7e8f9`
+	if result.Output() != expected {
+		t.Fatalf("expect output:\n%s\nGot:\n%s", expected, result.Output())
 	}
 }
 
-// TestVersionCheckListofAgents gets list of agents by parsing mesos endpoint /slaves
-func TestVersionCheckListOfAgents(t *testing.T) {
+func TestVersionLess(t *testing.T) {
 	for _, testCase := range []struct {
-		role      string
-		forceTLS  bool
-		status    int
-		response  string
-		expStatus int
-		expValue  string
-		version   string
+		a, b     string
+		expected bool
 	}{
-		{
-			role:      "master",
-			forceTLS:  false,
-			status:    http.StatusOK,
-			response:  `{"slaves":[{"id":"529c3971-b5bb-4f9e-b817-bb32def0ede2-S1","hostname":"10.0.6.233","port":5051,"attributes":{"public_ip":"true"},"pid":"slave(1)@10.0.6.233:5051","registered_time":1496728541.24296,"resources":{"disk":35566.0,"mem":14021.0,"gpus":0.0,"cpus":4.0,"ports":"[1-21, 23-5050, 5052-32000]"},"used_resources":{"disk":0.0,"mem":0.0,"gpus":0.0,"cpus":0.0},"offered_resources":{"disk":0.0,"mem":0.0,"gpus":0.0,"cpus":0.0},"reserved_resources":{"slave_public":{"disk":35566.0,"mem":14021.0,"gpus":0.0,"cpus":4.0,"ports":"[1-21, 23-5050, 5052-32000]"}},"unreserved_resources":{"disk":0.0,"mem":0.0,"gpus":0.0,"cpus":0.0},"active":true,"version":"1.3.0","capabilities":["MULTI_ROLE"],"reserved_resources_full":{"slave_public":[{"name":"ports","type":"RANGES","ranges":{"range":[{"begin":1,"end":21},{"begin":23,"end":5050},{"begin":5052,"end":32000}]},"role":"slave_public"},{"name":"disk","type":"SCALAR","scalar":{"value":35566.0},"role":"slave_public"},{"name":"cpus","type":"SCALAR","scalar":{"value":4.0},"role":"slave_public"},{"name":"mem","type":"SCALAR","scalar":{"value":14021.0},"role":"slave_public"}]},"used_resources_full":[],"offered_resources_full":[]}]}`,
-			expStatus: constants.StatusOK,
-			expValue:  "10.0.6.233",
-			version:   `{"version": "1.10-dev", "dcos-image-commit": "ccb53df0da261508249570df577c47bbbcc09f82", "bootstrap-id": "8468e43583e21ccb482ff303ed7496f84bbadb4d"}`,
-		},
+		{"1.9.4", "1.10.0", true},
+		{"1.10.0", "1.9.4", false},
+		{"1.10.0", "1.10.0", false},
+		{"1.10", "1.10.0", true},
+		{"1.10.0", "1.10-dev", true},
+		{"1.10-beta", "1.10-dev", true},
+		{"1.11.0", "1.10.2", false},
 	} {
-		mockCLICfg := &common.CLIConfigFlags{
-			NodeIPStr: "127.0.0.1",
-			Role:      testCase.role,
-			ForceTLS:  testCase.forceTLS,
+		if less := versionLess(testCase.a, testCase.b); less != testCase.expected {
+			t.Fatalf("expect versionLess(%s, %s) to be %t", testCase.a, testCase.b, testCase.expected)
 		}
+	}
+}
 
-		agentHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.WriteHeader(testCase.status)
-			w.Header().Set("Content-Type", "application/json")
-			if r.URL.Path == "/slaves" {
-				io.WriteString(w, testCase.response)
-			}
-			if r.URL.Path == "/dcos-metadata/dcos-version.json" {
-				io.WriteString(w, testCase.version)
-			}
-		})
+func TestVersionCheckExpectedVersion(t *testing.T) {
+	cluster := fakecluster.New(fakecluster.MixedVersions())
+	defer cluster.Close()
 
-		agentServer := httptest.NewServer(agentHandler)
-		defer agentServer.Close()
+	flags := pflag.NewFlagSet("version", pflag.ContinueOnError)
+	addFlags(flags)
+	if err := flags.Parse([]string{"--expected-version", "1.10.0"}); err != nil {
+		t.Fatal(err)
+	}
 
-		test := &versionCheck{
-			Name:          "TEST",
-			ClusterLeader: "127.0.0.1",
-		}
+	check, err := newCheckFromFlags(flags, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
 
-		testurl, err := url.Parse(agentServer.URL)
-		if err != nil {
-			t.Fatalf("could not parse")
+	result := check.(*versionCheck).RunDetailed(cluster.Context(context.TODO()), &common.CLIConfigFlags{Role: "master"})
+	expected := "2 nodes are not at the expected DC/OS version 1.10.0: 10.0.1.1, 10.0.2.1; " +
+		"More than 2 DC/OS versions on the cluster: 1.9.4, 1.10.0, 1.11.0"
+	if result.Status != constants.StatusFailure || result.Summary != expected {
+		t.Fatalf("expect status %d and summary %q. Got %d: %q %v", constants.StatusFailure, expected,
+			result.Status, result.Summary, result.Err)
+	}
+
+	for _, item := range result.Items {
+		failed := item.Name == "10.0.1.1" || item.Name == "10.0.2.1"
+		if failed != (item.Status == constants.StatusFailure) {
+			t.Fatalf("unexpected status of node %s: %+v", item.Name, item)
 		}
-		var agenturlopt common.URLFields
-		agenturlopt.Host = testurl.Host
-		agenturlopt.Port = 0
-		agenturlopt.Path = "/slaves"
+	}
+}
 
-		agents, err := test.ListOfAgents(mockCLICfg, agenturlopt)
+func TestVersionReportBootstrapID(t *testing.T) {
+	report := newVersionReport([]nodeVersion{
+		{Host: "10.0.0.1", Role: "master", Version: "1.10.0", BootstrapID: "a"},
+		{Host: "10.0.1.1", Role: "agent", Version: "1.10.0", BootstrapID: "b"},
+		{Host: "10.0.1.2", Role: "agent", Version: "1.10.0", BootstrapID: "a"},
+		{Host: "10.0.1.3", Role: "agent", Version: "1.11.0", BootstrapID: "c"},
+	}, nil, nil)
 
-		if err != nil {
-			t.Fatalf("Status %s", err)
-		}
-		if agents[0] != testCase.expValue {
-			t.Fatalf("Getting random nonsense, not the correct value")
+	result := report.result("", unreachablePolicy{Action: unreachableWarn})
+	expected := "nodes on DC/OS version 1.10.0 have different bootstrap IDs: a (10.0.0.1, 10.0.1.2), b (10.0.1.1)"
+	if result.Status != constants.StatusFailure || result.Summary != expected {
+		t.Fatalf("expect status %d and summary %q. Got %d: %q", constants.StatusFailure, expected, result.Status, result.Summary)
+	}
+
+	for _, item := range result.Items {
+		if (item.Name == "10.0.1.1") != (item.Status == constants.StatusFailure) {
+			t.Fatalf("expect only the node with the minority bootstrap ID to fail. Got %+v", result.Items)
 		}
+	}
+}
 
-		var versionurlopt common.URLFields
-		versionurlopt.Host = testurl.Host
-		versionurlopt.Port = 0
-		versionurlopt.Path = "/dcos-metadata/dcos-version.json"
+func TestVersionCheckUnreachable(t *testing.T) {
+	scenario := fakecluster.Healthy()
+	node, _ := scenario.Node("10.0.1.2")
+	node.Unreachable = true
+	node, _ = scenario.Node("10.0.2.1")
+	node.Recovered = true
 
-		version, err := test.GetVersion(mockCLICfg, versionurlopt)
+	cluster := fakecluster.New(scenario)
+	defer cluster.Close()
 
+	for _, testCase := range []struct {
+		args    []string
+		status  int
+		summary string
+	}{
+		{nil, constants.StatusWarning, "1 of 6 nodes are unreachable: 10.0.1.2"},
+		{[]string{"--unreachable", "fail"}, constants.StatusFailure, "1 of 6 nodes are unreachable: 10.0.1.2"},
+		{[]string{"--unreachable", "fail", "--max-unreachable", "1"}, constants.StatusOK, "DC/OS versions on the cluster: 1.10.0"},
+		{[]string{"--unreachable", "ignore"}, constants.StatusOK, "DC/OS versions on the cluster: 1.10.0"},
+	} {
+		flags := pflag.NewFlagSet("version", pflag.ContinueOnError)
+		addFlags(flags)
+		if err := flags.Parse(testCase.args); err != nil {
+			t.Fatal(err)
+		}
+
+		check, err := newCheckFromFlags(flags, nil)
 		if err != nil {
-			t.Fatalf("Status %s", err)
+			t.Fatal(err)
 		}
-		if version != "1.10-dev" {
-			t.Fatalf("Getting nonsense %s, not the correct value", version)
+
+		cfg := &common.CLIConfigFlags{Role: "master", ClusterWorkers: 2}
+		result := check.(*versionCheck).RunDetailed(cluster.Context(context.TODO()), cfg)
+		if result.Status != testCase.status || result.Summary != testCase.summary {
+			t.Fatalf("%v: expect status %d and summary %q. Got %d: %q %v", testCase.args, testCase.status,
+				testCase.summary, result.Status, result.Summary, result.Err)
 		}
 
+		if len(result.Items) != len(scenario.Nodes) {
+			t.Fatalf("expect an item for each node. Got %+v", result.Items)
+		}
+
+		recovered := result.Items[len(result.Items)-2]
+		if recovered.Name != "10.0.2.1" || !strings.HasSuffix(recovered.Output, "recovered agent") {
+			t.Fatalf("expect the recovered agent to be queried. Got %+v", recovered)
+		}
+	}
+}
+
+func TestVersionCheckInvalidVersion(t *testing.T) {
+	scenario := fakecluster.Healthy()
+	node, _ := scenario.Node("10.0.1.1")
+	node.InvalidVersion = true
+
+	cluster := fakecluster.New(scenario)
+	defer cluster.Close()
+
+	flags := pflag.NewFlagSet("version", pflag.ContinueOnError)
+	addFlags(flags)
+	if err := flags.Parse([]string{"--unreachable", "ignore"}); err != nil {
+		t.Fatal(err)
+	}
+
+	check, err := newCheckFromFlags(flags, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := check.(*versionCheck).RunDetailed(cluster.Context(context.TODO()), &common.CLIConfigFlags{Role: "master"})
+	expected := "1 nodes did not return a valid DC/OS version: 10.0.1.1"
+	if result.Status != constants.StatusFailure || result.Summary != expected {
+		t.Fatalf("expect status %d and summary %q. Got %d: %q %v", constants.StatusFailure, expected,
+			result.Status, result.Summary, result.Err)
+	}
+
+	if failed, ok := result.Data["failed"].([]nodeError); !ok || len(failed) != 1 || failed[0].Host != "10.0.1.1" {
+		t.Fatalf("expect the node to be reported as failed. Got %+v", result.Data["failed"])
+	}
+
+	expectedDetails := "failed:\n  10.0.1.1 (agent): Unable to marshal response: invalid character '<'"
+	if !strings.Contains(result.Details, expectedDetails) {
+		t.Fatalf("expect details to contain %q. Got %s", expectedDetails, result.Details)
+	}
+}
+
+func TestVersionCheckInvalidUnreachablePolicy(t *testing.T) {
+	flags := pflag.NewFlagSet("version", pflag.ContinueOnError)
+	addFlags(flags)
+	if err := flags.Parse([]string{"--unreachable", "panic"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := newCheckFromFlags(flags, nil); err == nil {
+		t.Fatal("expect an error for an invalid unreachable policy")
+	}
+}
+
+func TestVersionCheckExplain(t *testing.T) {
+	vc := newVersionCheck("TEST")
+	actions, err := vc.Explain(context.TODO(), &common.CLIConfigFlags{ForceTLS: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"GET https://leader.mesos:8123/v1/hosts/master.mesos (auth: none) - list masters",
+		"GET https://leader.mesos:5050/slaves (auth: none) - list agents",
+		"GET https://<master ip>:443/dcos-metadata/dcos-version.json (auth: none) - DC/OS version of every master",
+		"GET https://<agent ip>:61002/dcos-metadata/dcos-version.json (auth: none) - DC/OS version of every agent",
+	}
+
+	if len(actions) != len(expected) {
+		t.Fatalf("expect %d actions. Got %v", len(expected), actions)
+	}
+
+	for i, action := range actions {
+		if action.String() != expected[i] {
+			t.Fatalf("expect action %q. Got %q", expected[i], action)
+		}
 	}
 }
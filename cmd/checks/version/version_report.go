@@ -0,0 +1,338 @@
+package version
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dcos/dcos-checks/common"
+	"github.com/dcos/dcos-checks/constants"
+)
+
+// maxVersions is the maximum number of DC/OS versions running on the cluster during an upgrade.
+const maxVersions = 2
+
+// unreachablePolicy describes how unreachable nodes affect the check status.
+type unreachablePolicy struct {
+	// Action is unreachableWarn, unreachableFail or unreachableIgnore.
+	Action string
+
+	// Max is the number of unreachable nodes tolerated before the action applies.
+	Max int
+}
+
+// status returns the check status of the given number of unreachable nodes.
+func (p unreachablePolicy) status(unreachable int) int {
+	if unreachable <= p.Max {
+		return constants.StatusOK
+	}
+
+	switch p.Action {
+	case unreachableIgnore:
+		return constants.StatusOK
+	case unreachableFail:
+		return constants.StatusFailure
+	default:
+		return constants.StatusWarning
+	}
+}
+
+// perfData returns the performance data of the unreachable nodes with the policy thresholds.
+func (p unreachablePolicy) perfData(unreachable, total int) common.PerfData {
+	data := common.PerfData{
+		Label: "unreachable_nodes",
+		Value: float64(unreachable),
+		Min:   "0",
+		Max:   fmt.Sprint(total),
+	}
+
+	switch p.Action {
+	case unreachableWarn:
+		data.Warning = fmt.Sprint(p.Max)
+	case unreachableFail:
+		data.Critical = fmt.Sprint(p.Max)
+	}
+	return data
+}
+
+// versionReport is the DC/OS version matrix of the cluster.
+type versionReport struct {
+	nodes []nodeVersion
+
+	// failed are the reachable nodes which did not return a valid version.
+	failed []nodeError
+
+	// unreachable are the nodes which could not be reached.
+	unreachable []nodeError
+
+	// versions maps a DC/OS version to the nodes running it.
+	versions map[string][]string
+
+	// bootstrapIDs maps a DC/OS version to the bootstrap IDs of its nodes and the nodes with each ID.
+	bootstrapIDs map[string]map[string][]string
+}
+
+// newVersionReport returns a report of the node versions.
+func newVersionReport(nodes []nodeVersion, failed, unreachable []nodeError) *versionReport {
+	r := &versionReport{
+		nodes:        nodes,
+		failed:       failed,
+		unreachable:  unreachable,
+		versions:     make(map[string][]string),
+		bootstrapIDs: make(map[string]map[string][]string),
+	}
+
+	for _, node := range nodes {
+		r.versions[node.Version] = append(r.versions[node.Version], node.Host)
+		if r.bootstrapIDs[node.Version] == nil {
+			r.bootstrapIDs[node.Version] = make(map[string][]string)
+		}
+		r.bootstrapIDs[node.Version][node.BootstrapID] = append(r.bootstrapIDs[node.Version][node.BootstrapID], node.Host)
+	}
+	return r
+}
+
+// sortedVersions returns the DC/OS versions of the cluster in ascending order, e.g. 1.9.4 before 1.10.0.
+func (r *versionReport) sortedVersions() []string {
+	versions := make([]string, 0, len(r.versions))
+	for version := range r.versions {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versionLess(versions[i], versions[j])
+	})
+	return versions
+}
+
+// versionLess returns true if version a is lower than version b. The versions are compared part by
+// part, numeric parts as numbers and other parts, e.g. dev of 1.10-dev, lexically. A numeric part is
+// lower than a non-numeric one.
+func versionLess(a, b string) bool {
+	isSeparator := func(r rune) bool { return r == '.' || r == '-' }
+	partsA, partsB := strings.FieldsFunc(a, isSeparator), strings.FieldsFunc(b, isSeparator)
+	for i := 0; i < len(partsA) && i < len(partsB); i++ {
+		if partsA[i] == partsB[i] {
+			continue
+		}
+
+		numA, errA := strconv.Atoi(partsA[i])
+		numB, errB := strconv.Atoi(partsB[i])
+		switch {
+		case errA == nil && errB == nil:
+			return numA < numB
+		case errA == nil || errB == nil:
+			return errA == nil
+		default:
+			return partsA[i] < partsB[i]
+		}
+	}
+
+	if len(partsA) != len(partsB) {
+		return len(partsA) < len(partsB)
+	}
+	return a < b
+}
+
+// majorityBootstrapID returns the bootstrap ID of most nodes running the version. A tie is broken
+// by the lexical order of the IDs.
+func (r *versionReport) majorityBootstrapID(version string) string {
+	var majority string
+	for id, hosts := range r.bootstrapIDs[version] {
+		n := len(r.bootstrapIDs[version][majority])
+		if len(hosts) > n || (len(hosts) == n && id < majority) {
+			majority = id
+		}
+	}
+	return majority
+}
+
+// inconsistentVersions returns the versions whose nodes have different bootstrap IDs.
+func (r *versionReport) inconsistentVersions() []string {
+	var versions []string
+	for _, version := range r.sortedVersions() {
+		if len(r.bootstrapIDs[version]) > 1 {
+			versions = append(versions, version)
+		}
+	}
+	return versions
+}
+
+// result returns a check result with an item for each node. A node fails if it does not run the
+// expected version, if set, or if its bootstrap ID differs from the majority of the nodes on the
+// same version, or if it does not return a valid version. More than maxVersions versions on the
+// cluster is a warning. Unreachable nodes affect the status according to the policy.
+func (r *versionReport) result(expected string, policy unreachablePolicy) common.CheckResult {
+	versions := r.sortedVersions()
+	total := len(r.nodes) + len(r.failed) + len(r.unreachable)
+	result := common.CheckResult{
+		Status: constants.StatusOK,
+		Data: map[string]interface{}{
+			"versions":      r.versions,
+			"bootstrap_ids": r.bootstrapIDs,
+			"nodes":         r.nodes,
+			"failed":        r.failed,
+			"unreachable":   r.unreachable,
+		},
+		PerfData: []common.PerfData{{
+			Label:   "versions",
+			Value:   float64(len(r.versions)),
+			Warning: fmt.Sprint(maxVersions),
+			Min:     "1",
+		}, policy.perfData(len(r.unreachable), total)},
+	}
+
+	var unexpected []string
+	for _, node := range r.nodes {
+		item := common.ItemResult{
+			Name:   node.Host,
+			Status: constants.StatusOK,
+			Output: fmt.Sprintf("DC/OS version %s, image commit %s, bootstrap ID %s", node.Version, node.ImageCommit, node.BootstrapID),
+		}
+
+		if node.Recovered {
+			item.Output += ", recovered agent"
+		}
+
+		if expected != "" && node.Version != expected {
+			item.Status = constants.StatusFailure
+			item.Output += fmt.Sprintf(", expected version %s", expected)
+			unexpected = append(unexpected, node.Host)
+		}
+
+		if majority := r.majorityBootstrapID(node.Version); node.BootstrapID != majority {
+			item.Status = constants.StatusFailure
+			item.Output += fmt.Sprintf(", expected bootstrap ID %s", majority)
+		}
+		result.Items = append(result.Items, item)
+	}
+
+	var failed []string
+	for _, node := range r.failed {
+		failed = append(failed, node.Host)
+		result.Items = append(result.Items, common.ItemResult{
+			Name:   node.Host,
+			Status: constants.StatusFailure,
+			Output: fmt.Sprintf("failed: %s", node.Error),
+		})
+	}
+
+	unreachableStatus := policy.status(len(r.unreachable))
+	var unreachable []string
+	for _, node := range r.unreachable {
+		unreachable = append(unreachable, node.Host)
+		result.Items = append(result.Items, common.ItemResult{
+			Name:   node.Host,
+			Status: unreachableStatus,
+			Output: fmt.Sprintf("unreachable: %s", node.Error),
+		})
+	}
+
+	var problems []string
+	if expected != "" {
+		result.PerfData = append(result.PerfData, common.PerfData{
+			Label:    "unexpected_version_nodes",
+			Value:    float64(len(unexpected)),
+			Critical: "0",
+			Min:      "0",
+			Max:      fmt.Sprint(len(r.nodes)),
+		})
+	}
+
+	if len(unexpected) > 0 {
+		result.Status = constants.StatusFailure
+		problems = append(problems, fmt.Sprintf("%d nodes are not at the expected DC/OS version %s: %s",
+			len(unexpected), expected, strings.Join(unexpected, ", ")))
+		result.Remediation = append(result.Remediation, common.Remediation{
+			Hint: fmt.Sprintf("Upgrade %s to DC/OS %s", strings.Join(unexpected, ", "), expected),
+		})
+	}
+
+	for _, version := range r.inconsistentVersions() {
+		result.Status = constants.StatusFailure
+
+		ids := make([]string, 0, len(r.bootstrapIDs[version]))
+		for id, hosts := range r.bootstrapIDs[version] {
+			ids = append(ids, fmt.Sprintf("%s (%s)", id, strings.Join(hosts, ", ")))
+		}
+		sort.Strings(ids)
+
+		problems = append(problems, fmt.Sprintf("nodes on DC/OS version %s have different bootstrap IDs: %s",
+			version, strings.Join(ids, ", ")))
+		result.Remediation = append(result.Remediation, common.Remediation{
+			Hint: fmt.Sprintf("Reinstall the nodes on DC/OS %s which do not have bootstrap ID %s using the installer of the cluster",
+				version, r.majorityBootstrapID(version)),
+		})
+	}
+
+	if len(failed) > 0 {
+		result.Status = constants.StatusFailure
+		problems = append(problems, fmt.Sprintf("%d nodes did not return a valid DC/OS version: %s", len(failed),
+			strings.Join(failed, ", ")))
+		result.Remediation = append(result.Remediation, common.Remediation{
+			Hint: fmt.Sprintf("Make sure adminrouter serves /dcos-metadata/dcos-version.json on %s", strings.Join(failed, ", ")),
+		})
+	}
+
+	if unreachableStatus != constants.StatusOK {
+		if unreachableStatus > result.Status {
+			result.Status = unreachableStatus
+		}
+		problems = append(problems, fmt.Sprintf("%d of %d nodes are unreachable: %s", len(unreachable), total,
+			strings.Join(unreachable, ", ")))
+		result.Remediation = append(result.Remediation, common.Remediation{
+			Hint: fmt.Sprintf("Make sure adminrouter is running and reachable from the master on %s", strings.Join(unreachable, ", ")),
+		})
+	}
+
+	if len(versions) > maxVersions {
+		if result.Status < constants.StatusWarning {
+			result.Status = constants.StatusWarning
+		}
+		problems = append(problems, fmt.Sprintf("More than %d DC/OS versions on the cluster: %s", maxVersions, strings.Join(versions, ", ")))
+		result.Remediation = append(result.Remediation, common.Remediation{
+			Hint: "Finish the upgrade of every node, a cluster must not run more than 2 DC/OS versions",
+		})
+	}
+
+	result.Summary = fmt.Sprintf("DC/OS versions on the cluster: %s", strings.Join(versions, ", "))
+	if len(problems) > 0 {
+		result.Summary = strings.Join(problems, "; ")
+	}
+
+	// the version matrix of the cluster.
+	var details []string
+	for _, version := range versions {
+		details = append(details, fmt.Sprintf("%s:", version))
+		for _, node := range r.nodes {
+			if node.Version == version {
+				details = append(details, fmt.Sprintf("  %s (%s): image commit %s, bootstrap ID %s",
+					node.Host, roleName(node.Role, node.Recovered), node.ImageCommit, node.BootstrapID))
+			}
+		}
+	}
+
+	if len(r.failed) > 0 {
+		details = append(details, "failed:")
+		for _, node := range r.failed {
+			details = append(details, fmt.Sprintf("  %s (%s): %s", node.Host, roleName(node.Role, node.Recovered), node.Error))
+		}
+	}
+
+	if len(r.unreachable) > 0 {
+		details = append(details, "unreachable:")
+		for _, node := range r.unreachable {
+			details = append(details, fmt.Sprintf("  %s (%s): %s", node.Host, roleName(node.Role, node.Recovered), node.Error))
+		}
+	}
+	result.Details = strings.Join(details, "\n")
+	return result
+}
+
+// roleName returns the role of a node marking recovered agents.
+func roleName(role string, recovered bool) string {
+	if recovered {
+		return role + ", recovered"
+	}
+	return role
+}
@@ -6,3 +6,21 @@ type versionResponse struct {
 	DcosImageCommit string `json:"dcos-image-commit"`
 	BootstrapID     string `json:"bootstrap-id"`
 }
+
+// nodeVersion is the DC/OS version of a node.
+type nodeVersion struct {
+	Host        string `json:"host" yaml:"host"`
+	Role        string `json:"role" yaml:"role"`
+	Recovered   bool   `json:"recovered,omitempty" yaml:"recovered,omitempty"`
+	Version     string `json:"version" yaml:"version"`
+	ImageCommit string `json:"dcos_image_commit" yaml:"dcos_image_commit"`
+	BootstrapID string `json:"bootstrap_id" yaml:"bootstrap_id"`
+}
+
+// nodeError is a node whose version could not be queried.
+type nodeError struct {
+	Host      string `json:"host" yaml:"host"`
+	Role      string `json:"role" yaml:"role"`
+	Recovered bool   `json:"recovered,omitempty" yaml:"recovered,omitempty"`
+	Error     string `json:"error" yaml:"error"`
+}
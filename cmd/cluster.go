@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/dcos/dcos-checks/common"
+	"github.com/sirupsen/logrus"
+)
+
+// tasksFunc returns tasks applicable to a node with the given role.
+type tasksFunc func(role string) ([]common.Task, error)
+
+// runTasksAndExit runs the checks on this node, or against every node of the cluster if --cluster is set.
+// With --explain the checks of this node are explained instead.
+func runTasksAndExit(tasks tasksFunc) {
+	if common.DCOSConfig.Explain {
+		explainTasksAndExit(tasks)
+	}
+
+	if common.DCOSConfig.Cluster {
+		runClusterAndExit(tasks)
+	}
+
+	nodeTasks, err := tasks(common.DCOSConfig.Role)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+
+	common.RunChecksAndExit(context.TODO(), nodeTasks)
+}
+
+// explainTasksAndExit prints the actions of the checks applicable to this node.
+func explainTasksAndExit(tasks tasksFunc) {
+	if common.DCOSConfig.Cluster {
+		logrus.Warn("--explain describes the checks of this node, --cluster is ignored")
+	}
+
+	nodeTasks, err := tasks(common.DCOSConfig.Role)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+
+	common.ExplainTasksAndExit(context.TODO(), nodeTasks)
+}
+
+// runClusterAndExit discovers the cluster nodes and runs HTTP based node checks against every node.
+// Checks which inspect the local node only or the whole cluster are skipped.
+func runClusterAndExit(tasks tasksFunc) {
+	// the run context queries the local node if it is the leader and is shared by all node checks.
+	rc := common.NewRunContext(common.DCOSConfig)
+	ctx := rc.Context(context.Background())
+	nodes, err := rc.Nodes(ctx)
+	if err != nil {
+		logrus.Fatalf("Unable to discover cluster nodes: %s", err)
+	}
+
+	common.RunClusterChecksAndExit(ctx, nodes, func(node common.Node) ([]common.Task, error) {
+		nodeTasks, err := tasks(node.Role)
+		if err != nil {
+			return nil, err
+		}
+		return remoteTasks(nodeTasks), nil
+	}, common.DCOSConfig.ClusterWorkers)
+}
+
+// remoteTasks returns tasks which can be executed against a remote node.
+func remoteTasks(tasks []common.Task) []common.Task {
+	var remote []common.Task
+	for _, task := range tasks {
+		if !task.HasTag("http") || task.HasTag("cluster") {
+			logrus.Debugf("Skipping check %s in cluster mode", task.Name)
+			continue
+		}
+		remote = append(remote, task)
+	}
+	return remote
+}
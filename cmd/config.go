@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/dcos/dcos-checks/common"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage dcos-checks-config",
+}
+
+// configValidateCmd represents the config validate command
+var configValidateCmd = &cobra.Command{
+	Use:   "validate [config file]",
+	Short: "Validate the config file",
+	Long: `Load the config file and validate every key. The config file is the given file,
+the file set by --config or dcos-checks-config in /opt/mesosphere/etc/ in any format
+supported by viper (YAML, JSON, TOML).
+
+Top level keys must be global flags, "suites" or "params". The roles must be valid DC/OS roles,
+iam-config, ca-cert and detect-ip must be existing files and node-ip must be an IP address.
+Suites and params must reference registered checks and their parameters.
+
+Every invalid key is printed along with the error. The command exits with a non-zero
+code if the config file can not be read or is invalid.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		v := viper.New()
+		switch {
+		case len(args) > 0:
+			v.SetConfigFile(args[0])
+		case cfgFile != "":
+			v.SetConfigFile(cfgFile)
+		default:
+			v.SetConfigName("dcos-checks-config")
+			v.AddConfigPath("/opt/mesosphere/etc/")
+		}
+
+		if err := v.ReadInConfig(); err != nil {
+			logrus.Fatalf("Unable to read config file: %s", err)
+		}
+
+		// AllSettings drops empty maps, e.g. suite checks without options, so read
+		// the raw values of the top level keys.
+		settings := make(map[string]interface{})
+		for key := range v.AllSettings() {
+			settings[key] = v.Get(key)
+		}
+
+		errs := common.ValidateConfig(settings, rootCmd.PersistentFlags())
+		for _, err := range errs {
+			fmt.Printf("%s: %s\n", v.ConfigFileUsed(), err)
+		}
+
+		if len(errs) > 0 {
+			os.Exit(1)
+		}
+		fmt.Printf("%s is valid\n", v.ConfigFileUsed())
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configValidateCmd)
+	rootCmd.AddCommand(configCmd)
+}
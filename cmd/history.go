@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dcos/dcos-checks/common"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	historyNode  string
+	historyLimit int
+)
+
+// historyCmd represents the history command
+var historyCmd = &cobra.Command{
+	Use:   "history <check name>",
+	Short: "Show recorded results of a check",
+	Long: `Show the results of a check recorded in the history directory with --history.
+Use --node to show the results of a check executed against a node in cluster mode.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		history := common.History{Dir: common.DCOSConfig.HistoryDir}
+		entries, err := history.Load(common.HistoryKey(args[0], historyNode))
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		if historyLimit > 0 && len(entries) > historyLimit {
+			entries = entries[len(entries)-historyLimit:]
+		}
+
+		if err := printHistory(entries); err != nil {
+			logrus.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	historyCmd.Flags().StringVar(&historyNode, "node", "", "show results of a check executed against the node in cluster mode")
+	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 0, "show the given number of the latest results")
+	rootCmd.AddCommand(historyCmd)
+}
+
+// printHistory writes the entries to stdout in the output format set by --output.
+func printHistory(entries []common.HistoryEntry) error {
+	switch common.DCOSConfig.Output {
+	case common.OutputJSON:
+		encoder := json.NewEncoder(os.Stdout)
+		for _, entry := range entries {
+			if err := encoder.Encode(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	case common.OutputYAML:
+		for _, entry := range entries {
+			body, err := yaml.Marshal(entry)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("---\n%s", body)
+		}
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tSTATUS\tDURATION\tMESSAGE")
+	for _, entry := range entries {
+		message := entry.Output
+		if entry.Error != "" {
+			message = entry.Error
+		}
+
+		if i := strings.Index(message, "\n"); i >= 0 {
+			message = message[:i]
+		}
+
+		duration := time.Duration(entry.Duration * float64(time.Second))
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", entry.Time.Format(time.RFC3339), common.StatusName(entry.Status),
+			duration, message)
+	}
+	return w.Flush()
+}
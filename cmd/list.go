@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dcos/dcos-checks/common"
+	"github.com/spf13/cobra"
+)
+
+// listCmd represents the list command
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List available checks",
+	Long:  `List all registered checks with their roles, tags, default timeouts and descriptions.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tROLES\tTAGS\tREQUIRES\tTIMEOUT\tCLUSTER\tDESCRIPTION")
+		for _, spec := range common.Checks() {
+			timeout := "-"
+			if spec.Timeout > 0 {
+				timeout = spec.Timeout.String()
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%t\t%s\n", spec.Name, listOrDash(spec.Roles),
+				listOrDash(spec.Tags), listOrDash(spec.Requires), timeout, spec.ClusterAccess, spec.Description)
+		}
+		w.Flush()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(listCmd)
+}
+
+func listOrDash(items []string) string {
+	if len(items) == 0 {
+		return "-"
+	}
+	return strings.Join(items, ",")
+}
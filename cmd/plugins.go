@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/dcos/dcos-checks/plugin"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// addPluginSubcommands registers plugin checks found in the plugin directories and adds
+// a subcommand for each of them. Plugins named like a builtin subcommand are skipped.
+func addPluginSubcommands(args []string) {
+	dirs := pluginDirsFromArgs(args, pluginDirsFromConfig(args, pluginDirs))
+	for _, spec := range plugin.Register(dirs, commandNames(rootCmd)) {
+		rootCmd.AddCommand(newCheckCommand(spec))
+	}
+}
+
+// commandNames returns the names and aliases of the subcommands of cmd, including the help
+// command cobra adds when the command line is parsed.
+func commandNames(cmd *cobra.Command) []string {
+	names := []string{"help"}
+	for _, sub := range cmd.Commands() {
+		names = append(names, sub.Name())
+		names = append(names, sub.Aliases...)
+	}
+	return names
+}
+
+// pluginDirsFromArgs returns the values of --plugin-dir flags in args, or the defaults if the
+// flag is not set. The plugins must be discovered before the command line is parsed, so the
+// flag is looked up manually.
+func pluginDirsFromArgs(args []string, defaults []string) []string {
+	var dirs []string
+	for _, value := range flagValuesFromArgs(args, "plugin-dir") {
+		dirs = append(dirs, strings.Split(value, ",")...)
+	}
+
+	if len(dirs) == 0 {
+		return defaults
+	}
+	return dirs
+}
+
+// pluginDirsFromConfig returns plugin-dir from the config file set by --config in args or
+// dcos-checks-config, or the defaults if the key is not set. initConfig runs after the command
+// line is parsed, so the config file is read separately.
+func pluginDirsFromConfig(args []string, defaults []string) []string {
+	v := viper.New()
+	if files := flagValuesFromArgs(args, "config"); len(files) > 0 {
+		v.SetConfigFile(files[len(files)-1])
+	} else {
+		v.SetConfigName("dcos-checks-config")
+		v.AddConfigPath("/opt/mesosphere/etc/")
+	}
+
+	// errors are reported by initConfig.
+	if err := v.ReadInConfig(); err != nil || !v.IsSet("plugin-dir") {
+		return defaults
+	}
+
+	dirs := v.GetStringSlice("plugin-dir")
+	logrus.Debugf("Using plugin directories %v from %s", dirs, v.ConfigFileUsed())
+	return dirs
+}
+
+// flagValuesFromArgs returns the values of the long flag with the given name in args.
+func flagValuesFromArgs(args []string, name string) []string {
+	var values []string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--":
+			i = len(args)
+		case args[i] == "--"+name && i+1 < len(args):
+			values = append(values, args[i+1])
+			i++
+		case strings.HasPrefix(args[i], "--"+name+"="):
+			values = append(values, strings.TrimPrefix(args[i], "--"+name+"="))
+		}
+	}
+	return values
+}
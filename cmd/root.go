@@ -15,14 +15,18 @@
 package cmd
 
 import (
+	"os"
+
 	"github.com/dcos/dcos-checks/common"
+	"github.com/dcos/dcos-checks/plugin"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 var (
-	cfgFile string
+	cfgFile    string
+	pluginDirs []string
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -37,12 +41,21 @@ The checks could be executed against a signle node, or a whole cluster.
 		if common.DCOSConfig.Verbose {
 			logrus.SetLevel(logrus.DebugLevel)
 		}
+
+		common.ResolveRole(common.DCOSConfig)
+
+		if err := common.ValidateOutputFormat(common.DCOSConfig.Output); err != nil {
+			logrus.Fatal(err)
+		}
 	},
 }
 
 // Execute adds all child commands to the root command sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
+	// plugin subcommands must be added before the command line is parsed.
+	addPluginSubcommands(os.Args[1:])
+
 	// run the commands parser
 	if err := rootCmd.Execute(); err != nil {
 		logrus.Fatalf("Error parsing subcommands: %s", err)
@@ -54,11 +67,33 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.checks.yaml)")
 	rootCmd.PersistentFlags().BoolVar(&common.DCOSConfig.ForceTLS, "force-tls", false, "use HTTPS for GET/POST requests")
 	rootCmd.PersistentFlags().BoolVar(&common.DCOSConfig.Verbose, "verbose", false, "enable verbose output")
-	rootCmd.PersistentFlags().StringVar(&common.DCOSConfig.Role, "role", "", "set DC/OS role. (valid roles: master, agent, public-agent)")
+	rootCmd.PersistentFlags().StringVar(&common.DCOSConfig.Role, "role", "", "set DC/OS role. (valid roles: master, agent, agent_public)")
+	rootCmd.PersistentFlags().StringVar(&common.DCOSConfig.RolesDir, "roles-dir", common.DefaultRolesDir, "a path to the directory with role marker files used to detect the role if --role is not set")
 	rootCmd.PersistentFlags().StringVar(&common.DCOSConfig.IAMConfig, "iam-config", "", "a path to identity and access managment config")
 	rootCmd.PersistentFlags().StringVar(&common.DCOSConfig.CACert, "ca-cert", "", "a path to certificate authority file")
 	rootCmd.PersistentFlags().StringVar(&common.DCOSConfig.DetectIP, "detect-ip", "/opt/mesosphere/bin/detect_ip", "a path to detect ip script")
 	rootCmd.PersistentFlags().StringVar(&common.DCOSConfig.NodeIPStr, "node-ip", "", "set node IP address overriding detect_ip output")
+	rootCmd.PersistentFlags().StringSliceVar(&pluginDirs, "plugin-dir", []string{plugin.DefaultDir}, "a directory to discover plugin checks in")
+	rootCmd.PersistentFlags().StringVarP(&common.DCOSConfig.Output, "output", "o", common.OutputText, "set output format. (valid formats: text, json, yaml, prometheus, junit, nagios)")
+	rootCmd.PersistentFlags().BoolVar(&common.DCOSConfig.JUnitWarningsAsFailures, "junit-warnings-as-failures", false, "report warnings as failures instead of skipped test cases in junit output")
+	rootCmd.PersistentFlags().BoolVar(&common.DCOSConfig.History, "history", false, "record check results in the history directory")
+	rootCmd.PersistentFlags().StringVar(&common.DCOSConfig.HistoryDir, "history-dir", common.DefaultHistoryDir, "a path to the check history directory")
+	rootCmd.PersistentFlags().IntVar(&common.DCOSConfig.HistorySize, "history-size", 100, "maximum number of results kept per check")
+	rootCmd.PersistentFlags().IntVar(&common.DCOSConfig.EscalateAfter, "escalate-after", 0, "escalate a warning to a failure after the given number of consecutive non-OK results")
+	rootCmd.PersistentFlags().IntVar(&common.DCOSConfig.FlapThreshold, "flap-threshold", 0, "mark a check as flapping if its status changed at least the given number of times within --flap-window runs")
+	rootCmd.PersistentFlags().IntVar(&common.DCOSConfig.FlapWindow, "flap-window", 10, "number of the latest results used for flap detection")
+	rootCmd.PersistentFlags().BoolVar(&common.DCOSConfig.Explain, "explain", false, "print the HTTP requests, commands and files each check would use without executing the checks")
+	rootCmd.PersistentFlags().BoolVar(&common.DCOSConfig.Cluster, "cluster", false, "run HTTP based node checks against every node of the cluster")
+	rootCmd.PersistentFlags().IntVar(&common.DCOSConfig.ClusterWorkers, "cluster-workers", 10, "maximum number of nodes checked concurrently in cluster mode")
+	rootCmd.PersistentFlags().StringVar(&common.DCOSConfig.PrometheusTextfile, "prometheus-textfile", "", "write check metrics to a file for node_exporter textfile collector")
+	rootCmd.PersistentFlags().DurationVar(&common.DCOSConfig.Timeout, "timeout", 0, "override default check timeout")
+	rootCmd.PersistentFlags().IntVar(&common.DCOSConfig.Retries, "retries", 0, "retry a check returning an error the given number of times")
+	rootCmd.PersistentFlags().DurationVar(&common.DCOSConfig.RetryBackoff, "retry-backoff", 0, "delay before the first retry, doubled after each retry (default 1s unless set by the suite)")
+
+	// flags set on the command line take precedence over the config file.
+	if err := viper.BindPFlags(rootCmd.PersistentFlags()); err != nil {
+		logrus.Fatalf("Error binding flags: %s", err)
+	}
 
 	// add the subpackage commands
 	addSubcommands()
@@ -77,12 +112,31 @@ func initConfig() {
 	// If a config file is found, read it in.
 	if err := viper.ReadInConfig(); err == nil {
 		logrus.Infof("Using config file: %s", viper.ConfigFileUsed())
-		common.DCOSConfig.Role = viper.GetString("role")
-		common.DCOSConfig.ForceTLS = viper.GetBool("force-tls")
-		common.DCOSConfig.Verbose = viper.GetBool("verbose")
-		common.DCOSConfig.IAMConfig = viper.GetString("iam-config")
-		common.DCOSConfig.CACert = viper.GetString("ca-cert")
-		common.DCOSConfig.DetectIP = viper.GetString("detect-ip")
-		common.DCOSConfig.NodeIPStr = viper.GetString("node-ip")
+	} else if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+		logrus.Warnf("Unable to read config file: %s. Run \"checks config validate\" for details", err)
 	}
+
+	common.DCOSConfig.Role = viper.GetString("role")
+	common.DCOSConfig.RolesDir = viper.GetString("roles-dir")
+	common.DCOSConfig.ForceTLS = viper.GetBool("force-tls")
+	common.DCOSConfig.Verbose = viper.GetBool("verbose")
+	common.DCOSConfig.IAMConfig = viper.GetString("iam-config")
+	common.DCOSConfig.CACert = viper.GetString("ca-cert")
+	common.DCOSConfig.DetectIP = viper.GetString("detect-ip")
+	common.DCOSConfig.NodeIPStr = viper.GetString("node-ip")
+	common.DCOSConfig.Output = viper.GetString("output")
+	common.DCOSConfig.PrometheusTextfile = viper.GetString("prometheus-textfile")
+	common.DCOSConfig.JUnitWarningsAsFailures = viper.GetBool("junit-warnings-as-failures")
+	common.DCOSConfig.Timeout = viper.GetDuration("timeout")
+	common.DCOSConfig.Retries = viper.GetInt("retries")
+	common.DCOSConfig.RetryBackoff = viper.GetDuration("retry-backoff")
+	common.DCOSConfig.History = viper.GetBool("history")
+	common.DCOSConfig.HistoryDir = viper.GetString("history-dir")
+	common.DCOSConfig.HistorySize = viper.GetInt("history-size")
+	common.DCOSConfig.EscalateAfter = viper.GetInt("escalate-after")
+	common.DCOSConfig.FlapThreshold = viper.GetInt("flap-threshold")
+	common.DCOSConfig.FlapWindow = viper.GetInt("flap-window")
+	common.DCOSConfig.Explain = viper.GetBool("explain")
+	common.DCOSConfig.Cluster = viper.GetBool("cluster")
+	common.DCOSConfig.ClusterWorkers = viper.GetInt("cluster-workers")
 }
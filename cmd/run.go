@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"github.com/dcos/dcos-checks/common"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// runCmd represents the run command
+var runCmd = &cobra.Command{
+	Use:   "run [check name...]",
+	Short: "Run multiple checks in one invocation",
+	Long: `Run the given checks concurrently, print a summary for each of them
+and exit with the worst status.
+
+If no check names are given, all checks applicable to the node --role are executed.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runTasksAndExit(func(role string) ([]common.Task, error) {
+			return selectTasks(args, role)
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(runCmd)
+}
+
+// selectTasks returns tasks for the given check names. If names are empty, all checks
+// for the given role are selected. The check parameters are taken from the config file.
+func selectTasks(names []string, role string) ([]common.Task, error) {
+	var selected []common.CheckSpec
+	if len(names) == 0 {
+		if role == "" {
+			return nil, errors.New("check names or --role must be set")
+		}
+
+		selected = common.ChecksForRole(role)
+		if len(selected) == 0 {
+			return nil, errors.Errorf("no checks available for role %s", role)
+		}
+	}
+
+	for _, name := range names {
+		spec, ok := common.LookupCheck(name)
+		if !ok {
+			return nil, errors.Errorf("unknown check %s", name)
+		}
+		selected = append(selected, spec)
+	}
+
+	tasks := make([]common.Task, 0, len(selected))
+	for _, spec := range selected {
+		task, err := spec.NewTask(nil, configParams(spec.Name))
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+
+	return tasks, nil
+}
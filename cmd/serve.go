@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/dcos/dcos-checks/common"
+	"github.com/dcos/dcos-checks/server"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveListen   string
+	serveInterval time.Duration
+	serveSuite    string
+)
+
+// defaultServeListen is the default address of the HTTP API, which is only reachable from the node.
+const defaultServeListen = "127.0.0.1:61099"
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:   "serve [check name...]",
+	Short: "Run checks on an interval and expose the results via HTTP API",
+	Long: `Run checks on an interval, cache the latest result of each check and expose
+the results via HTTP API:
+
+GET  /v1/checks             latest results of all checks
+GET  /v1/checks/<name>      latest result of a check
+POST /v1/checks/<name>/run  run a check and return the result
+POST /v1/run                run all checks and return the results
+GET  /v1/status             overall node status, 503 if a check failed
+GET  /metrics               latest results in Prometheus text exposition format
+
+Checks are selected by --suite, by check names or by the node --role.
+
+The API is not authenticated and the POST routes execute checks, including plugin
+executables, so it listens on the loopback interface by default. Set --listen, e.g.
+--listen :61099, to expose it on other interfaces behind a firewall or an
+authenticating proxy.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		tasks := func() ([]common.Task, error) {
+			if serveSuite != "" {
+				suite, err := loadSuite(serveSuite)
+				if err != nil {
+					return nil, err
+				}
+				return suite.Tasks(common.DCOSConfig.Role)
+			}
+			return selectTasks(args, common.DCOSConfig.Role)
+		}
+
+		// fail early if the checks cannot be initialized.
+		if _, err := tasks(); err != nil {
+			logrus.Fatal(err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		s := server.New(ctx, common.DCOSConfig, tasks, serveInterval)
+		go s.Start(ctx)
+
+		httpServer := &http.Server{
+			Addr:    serveListen,
+			Handler: s.Handler(),
+		}
+
+		go func() {
+			signals := make(chan os.Signal, 1)
+			signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+			<-signals
+			cancel()
+			httpServer.Shutdown(context.Background())
+		}()
+
+		logrus.Infof("Serving check results on %s", serveListen)
+		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logrus.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+	serveCmd.Flags().StringVarP(&serveListen, "listen", "l", defaultServeListen, "Set address to listen on")
+	serveCmd.Flags().DurationVarP(&serveInterval, "interval", "i", time.Minute, "Set interval between check runs")
+	serveCmd.Flags().StringVar(&serveSuite, "suite", "", "Run checks of the given suite")
+}
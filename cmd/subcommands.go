@@ -1,30 +1,68 @@
 package cmd
 
 import (
-	"github.com/dcos/dcos-checks/cmd/checks/components"
-	"github.com/dcos/dcos-checks/cmd/checks/executable"
-	"github.com/dcos/dcos-checks/cmd/checks/ip"
-	"github.com/dcos/dcos-checks/cmd/checks/journald"
-	"github.com/dcos/dcos-checks/cmd/checks/mesosmetrics"
-	"github.com/dcos/dcos-checks/cmd/checks/time"
-	"github.com/dcos/dcos-checks/cmd/checks/version"
+	"context"
+
+	// checks register themselves in the common check registry.
+	_ "github.com/dcos/dcos-checks/cmd/checks/components"
+	_ "github.com/dcos/dcos-checks/cmd/checks/executable"
+	_ "github.com/dcos/dcos-checks/cmd/checks/ip"
+	_ "github.com/dcos/dcos-checks/cmd/checks/journald"
+	_ "github.com/dcos/dcos-checks/cmd/checks/mesosmetrics"
+	_ "github.com/dcos/dcos-checks/cmd/checks/time"
+	_ "github.com/dcos/dcos-checks/cmd/checks/version"
+	"github.com/dcos/dcos-checks/common"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
-// RegisterFunc represents a function that adds a subcommand to the rootCmd.
-type RegisterFunc func(rootCmd *cobra.Command)
+// addSubcommands adds a subcommand to the rootCmd for each registered check.
+func addSubcommands() {
+	for _, spec := range common.Checks() {
+		rootCmd.AddCommand(newCheckCommand(spec))
+	}
+}
 
-// RegisterSubcommand calls the given RegisterFunc, passing in the root command.
-func RegisterSubcommand(fn RegisterFunc) {
-	fn(rootCmd)
+// configParams returns the parameters of the given check set by the params key of the config file.
+func configParams(name string) map[string]interface{} {
+	return viper.GetStringMap("params." + name)
 }
 
-func addSubcommands() {
-	RegisterSubcommand(components.Register)
-	RegisterSubcommand(executable.Register)
-	RegisterSubcommand(ip.Register)
-	RegisterSubcommand(journald.Register)
-	RegisterSubcommand(mesosmetrics.Register)
-	RegisterSubcommand(time.Register)
-	RegisterSubcommand(version.Register)
+// newCheckCommand returns a cobra command which runs the given check. Parameters not set on
+// the command line are taken from the config file.
+func newCheckCommand(spec common.CheckSpec) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   spec.Name,
+		Short: spec.Description,
+		Long:  spec.Long,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := spec.SetParams(cmd.Flags(), configParams(spec.Name)); err != nil {
+				logrus.Fatalf("Invalid params.%s in config file: %s", spec.Name, err)
+			}
+
+			if common.DCOSConfig.Explain || common.DCOSConfig.Cluster {
+				runTasksAndExit(func(role string) ([]common.Task, error) {
+					task, err := spec.TaskFromFlags(cmd.Flags(), args)
+					if err != nil {
+						return nil, err
+					}
+					return []common.Task{task}, nil
+				})
+			}
+
+			task, err := spec.TaskFromFlags(cmd.Flags(), args)
+			if err != nil {
+				logrus.Fatal(err)
+			}
+
+			common.RunTask(context.Background(), task)
+		},
+	}
+
+	if spec.Flags != nil {
+		spec.Flags(cmd.Flags())
+	}
+
+	return cmd
 }
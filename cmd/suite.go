@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"github.com/dcos/dcos-checks/common"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// suiteCmd represents the suite command
+var suiteCmd = &cobra.Command{
+	Use:   "suite <suite name>",
+	Short: "Run a suite of checks defined in the config file",
+	Long: `Run all checks of a named suite defined in the "suites" section of dcos-checks-config.
+Only checks applicable to the node --role are executed.
+
+Example:
+
+suites:
+  node-poststart:
+    checks:
+      components:
+        timeout: 10s
+        params:
+          exclude: [dcos-checks-poststart.service]
+      version:
+        roles: [master]
+`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		suite, err := loadSuite(args[0])
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		runTasksAndExit(func(role string) ([]common.Task, error) {
+			tasks, err := suite.Tasks(role)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid suite %s", args[0])
+			}
+			return tasks, nil
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(suiteCmd)
+}
+
+// loadSuites returns all suites defined in the config file.
+func loadSuites() (map[string]common.SuiteConfig, error) {
+	var suites map[string]common.SuiteConfig
+	if err := viper.UnmarshalKey("suites", &suites); err != nil {
+		return nil, errors.Wrap(err, "unable to read suites from config file")
+	}
+	return suites, nil
+}
+
+// loadSuite returns a suite with the given name.
+func loadSuite(name string) (common.SuiteConfig, error) {
+	suites, err := loadSuites()
+	if err != nil {
+		return common.SuiteConfig{}, err
+	}
+
+	suite, ok := suites[name]
+	if !ok {
+		return common.SuiteConfig{}, errors.Errorf("suite %s is not defined in config file", name)
+	}
+	return suite, nil
+}
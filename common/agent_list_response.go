@@ -0,0 +1,33 @@
+package common
+
+import (
+	"github.com/dcos/dcos-go/dcos"
+)
+
+// agentListResponse response for /slaves
+type agentListResponse struct {
+	Slaves []agentInfo `json:"slaves"`
+
+	// RecoveredSlaves are agents known from the registry which have not re-registered
+	// with the master after a failover yet.
+	RecoveredSlaves []agentInfo `json:"recovered_slaves"`
+}
+
+// agentInfo describes an agent listed by /slaves.
+type agentInfo struct {
+	ID         string `json:"id"`
+	Hostname   string `json:"hostname"`
+	Port       int    `json:"port"`
+	Attributes struct {
+		PublicIP string `json:"public_ip"`
+	} `json:"attributes"`
+}
+
+// node returns the cluster node of the agent.
+func (a agentInfo) node(recovered bool) Node {
+	role := dcos.RoleAgent
+	if a.Attributes.PublicIP == "true" {
+		role = dcos.RoleAgentPublic
+	}
+	return Node{IP: a.Hostname, Role: role, MesosID: a.ID, Recovered: recovered}
+}
@@ -0,0 +1,90 @@
+package common
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// CheckResult is a detailed outcome of a single check run.
+type CheckResult struct {
+	Status int
+
+	// Summary is a one line description of the outcome.
+	Summary string
+
+	// Details is an optional multi-line description following the summary.
+	Details string
+
+	// Data contains structured key/value data, e.g. the DC/OS versions found in the cluster.
+	Data map[string]interface{}
+
+	// Items contains the results of the individual items checked, e.g. systemd units.
+	Items []ItemResult
+
+	PerfData    []PerfData
+	Remediation []Remediation
+
+	// Duration is the time it took to run the check. It is set by RunCheckResult.
+	Duration time.Duration
+
+	Err error
+}
+
+// ItemResult is a result of a single item checked by a check.
+type ItemResult struct {
+	Name   string
+	Status int
+	Output string
+}
+
+// DetailedChecker is implemented by checks which return a CheckResult. Such checks implement
+// DCOSChecker.Run with CheckResult.Tuple, so they can be used wherever a DCOSChecker is expected.
+type DetailedChecker interface {
+	DCOSChecker
+	RunDetailed(context.Context, *CLIConfigFlags) CheckResult
+}
+
+// NewCheckResult returns a check result for the output, status and error returned by DCOSChecker.Run.
+// The first line of the output is the summary and the remaining lines are the details.
+func NewCheckResult(output string, status int, err error) CheckResult {
+	result := CheckResult{
+		Status:  status,
+		Summary: output,
+		Err:     err,
+	}
+
+	if i := strings.Index(output, "\n"); i >= 0 {
+		result.Summary, result.Details = output[:i], output[i+1:]
+	}
+	return result
+}
+
+// Output returns the summary followed by the details.
+func (r CheckResult) Output() string {
+	if r.Details == "" {
+		return r.Summary
+	}
+	return r.Summary + "\n" + r.Details
+}
+
+// Tuple returns the output, status and error as returned by DCOSChecker.Run.
+func (r CheckResult) Tuple() (string, int, error) {
+	return r.Output(), r.Status, r.Err
+}
+
+// RunCheckResult runs the check and returns its detailed result. Checks which do not implement
+// DetailedChecker are adapted with NewCheckResult.
+func RunCheckResult(ctx context.Context, cfg *CLIConfigFlags, check DCOSChecker) CheckResult {
+	start := time.Now()
+
+	var result CheckResult
+	if detailed, ok := check.(DetailedChecker); ok {
+		result = detailed.RunDetailed(ctx, cfg)
+	} else {
+		result = NewCheckResult(check.Run(ctx, cfg))
+	}
+
+	result.Duration = time.Since(start)
+	return result
+}
@@ -0,0 +1,79 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dcos/dcos-checks/constants"
+)
+
+type fakeDetailedCheck struct {
+	fakeCheck
+	result CheckResult
+}
+
+func (f fakeDetailedCheck) Run(ctx context.Context, cfg *CLIConfigFlags) (string, int, error) {
+	return f.RunDetailed(ctx, cfg).Tuple()
+}
+
+func (f fakeDetailedCheck) RunDetailed(context.Context, *CLIConfigFlags) CheckResult {
+	return f.result
+}
+
+func TestNewCheckResult(t *testing.T) {
+	e := errors.New("some error")
+	result := NewCheckResult("summary\ndetail 1\ndetail 2", constants.StatusWarning, e)
+	if result.Summary != "summary" || result.Details != "detail 1\ndetail 2" {
+		t.Fatalf("expect the first line to be the summary. Got %+v", result)
+	}
+
+	output, status, err := result.Tuple()
+	if output != "summary\ndetail 1\ndetail 2" || status != constants.StatusWarning || err != e {
+		t.Fatalf("expect the tuple to match the check output. Got %q, %d, %v", output, status, err)
+	}
+}
+
+func TestRunCheckResultAdapter(t *testing.T) {
+	result := RunCheckResult(context.TODO(), nil, newFakeCheck("all is good\ndetail", constants.StatusOK, nil))
+	if result.Summary != "all is good" || result.Details != "detail" || result.Status != constants.StatusOK {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	check := fakeDetailedCheck{result: CheckResult{
+		Status:   constants.StatusOK,
+		PerfData: []PerfData{{Label: "items", Value: 1}},
+	}}
+	result = RunCheckResult(context.TODO(), nil, check)
+	if len(result.PerfData) != 1 || result.PerfData[0].Label != "items" {
+		t.Fatalf("expect perf data of the check result. Got %+v", result.PerfData)
+	}
+}
+
+func TestRunChecksDetailed(t *testing.T) {
+	check := fakeDetailedCheck{result: CheckResult{
+		Status:  constants.StatusFailure,
+		Summary: "1 of 2 units unhealthy",
+		Data:    map[string]interface{}{"units": 2},
+		Items: []ItemResult{
+			{Name: "a.service", Status: constants.StatusOK},
+			{Name: "b.service", Status: constants.StatusFailure, Output: "b is down"},
+		},
+		Remediation: []Remediation{{Hint: "restart b"}},
+	}}
+
+	results := RunChecks(context.TODO(), nil, []Task{{Name: "units", Check: check}})
+	result := results[0]
+	if result.Output != "1 of 2 units unhealthy" || result.Status != constants.StatusFailure {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	if len(result.Items) != 2 || result.Data["units"] != 2 || len(result.Remediation) != 1 {
+		t.Fatalf("expect items, data and remediation of the check. Got %+v", result)
+	}
+
+	doc := NewResultDocuments(&CLIConfigFlags{NodeIPStr: "127.0.0.1"}, results)[0]
+	if len(doc.Items) != 2 || doc.Items[1].StatusName != "FAILURE" || doc.Items[1].Output != "b is down" {
+		t.Fatalf("expect item documents. Got %+v", doc.Items)
+	}
+}
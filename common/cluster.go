@@ -0,0 +1,216 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/dcos/dcos-checks/constants"
+	"github.com/dcos/dcos-go/dcos"
+	"github.com/pkg/errors"
+)
+
+// Node describes a DC/OS cluster node.
+type Node struct {
+	// IP is a node address, as reported by Mesos DNS for masters and by Mesos for agents.
+	IP string
+
+	// Role is a DC/OS role of the node.
+	Role string
+
+	// MesosID is the Mesos ID of an agent. It is empty for masters.
+	MesosID string
+
+	// Recovered is true for agents which have not re-registered with the leading master
+	// after a failover yet.
+	Recovered bool
+}
+
+// NodeResult contains results of the checks executed against a single node.
+type NodeResult struct {
+	Node    Node
+	Results []Result
+}
+
+// ListMasters returns the current list of masters in the cluster using Mesos DNS
+// endpoint /v1/hosts/master.mesos.
+func ListMasters(ctx context.Context, cfg *CLIConfigFlags, urlopt URLFields) ([]Node, error) {
+	var masterResponse masterListResponses
+	_, response, err := HTTPRequest(ctx, cfg, urlopt)
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to fetch list of masters")
+	}
+
+	if err := json.Unmarshal(response, &masterResponse); err != nil {
+		return nil, errors.Wrap(err, "Unable to unmarshal response")
+	}
+
+	var masters []Node
+	for _, addr := range masterResponse {
+		masters = append(masters, Node{IP: addr.IP, Role: dcos.RoleMaster})
+	}
+	return masters, nil
+}
+
+// ListAgents returns the current list of agents in the cluster using Mesos endpoint /slaves.
+// Recovered agents, which have not re-registered after a master failover yet, are listed last.
+func ListAgents(ctx context.Context, cfg *CLIConfigFlags, urlopt URLFields) ([]Node, error) {
+	var agentResponse agentListResponse
+	_, response, err := HTTPRequest(ctx, cfg, urlopt)
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to fetch list of agents")
+	}
+
+	if err := json.Unmarshal(response, &agentResponse); err != nil {
+		return nil, errors.Wrap(err, "Unable to unmarshal response")
+	}
+
+	var agents []Node
+	for _, agent := range agentResponse.Slaves {
+		agents = append(agents, agent.node(false))
+	}
+	for _, agent := range agentResponse.RecoveredSlaves {
+		agents = append(agents, agent.node(true))
+	}
+	return agents, nil
+}
+
+// DiscoverNodes returns all masters and agents of the cluster, using the given leader
+// to query Mesos DNS and Mesos.
+func DiscoverNodes(ctx context.Context, cfg *CLIConfigFlags, leader string) ([]Node, error) {
+	masters, err := ListMasters(ctx, cfg, URLFields{
+		Host: leader,
+		Port: constants.MesosDNSPort,
+		Path: "/v1/hosts/master.mesos",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	agents, err := ListAgents(ctx, cfg, URLFields{
+		Host: leader,
+		Port: constants.MesosMasterHTTPPort,
+		Path: "/slaves",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return append(masters, agents...), nil
+}
+
+// RunClusterChecks runs the checks returned by tasks against every node, with at most
+// workers nodes checked concurrently. Each node is checked with a copy of cfg with the
+// node IP and role set. The results are returned in the same order as the nodes.
+func RunClusterChecks(ctx context.Context, cfg *CLIConfigFlags, nodes []Node, tasks func(Node) ([]Task, error),
+	workers int) []NodeResult {
+	// all nodes share the HTTP client of the run.
+	ctx = withRunContext(ctx, cfg)
+
+	nodeResults := make([]NodeResult, len(nodes))
+	ForEachNode(nodes, workers, func(i int) {
+		nodeResults[i] = runNodeChecks(ctx, cfg, nodes[i], tasks)
+	})
+	return nodeResults
+}
+
+// ForEachNode calls fn with the index of every node, with at most workers calls running
+// concurrently, and returns once all calls returned.
+func ForEachNode(nodes []Node, workers int, fn func(i int)) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+
+	for i := range nodes {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
+
+// runNodeChecks runs the checks against a single node.
+func runNodeChecks(ctx context.Context, cfg *CLIConfigFlags, node Node, tasks func(Node) ([]Task, error)) NodeResult {
+	nodeCfg := *cfg
+	nodeCfg.NodeIPStr = node.IP
+	nodeCfg.Role = node.Role
+
+	nodeTasks, err := tasks(node)
+	if err != nil {
+		return NodeResult{
+			Node: node,
+			Results: []Result{{
+				Status: constants.StatusUnknown,
+				Err:    errors.Wrap(err, "unable to initialize checks"),
+				NodeIP: node.IP,
+				Role:   node.Role,
+			}},
+		}
+	}
+
+	results := RunChecks(ctx, &nodeCfg, nodeTasks)
+	for i := range results {
+		results[i].NodeIP = node.IP
+		results[i].Role = node.Role
+	}
+	results = ApplyHistory(cfg, results)
+
+	return NodeResult{Node: node, Results: results}
+}
+
+// RunClusterChecksAndExit is a helper function to run checks against every node, print
+// the results and exit with the worst status.
+func RunClusterChecksAndExit(ctx context.Context, nodes []Node, tasks func(Node) ([]Task, error), workers int) {
+	nodeResults := RunClusterChecks(ctx, DCOSConfig, nodes, tasks, workers)
+	results := ClusterResults(nodeResults)
+	writeTextfile(DCOSConfig, results)
+
+	if DCOSConfig.Output == OutputText || DCOSConfig.Output == "" {
+		PrintClusterSummary(os.Stdout, nodeResults)
+	} else if err := WriteResults(os.Stdout, DCOSConfig, results); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing results: %s\n", err)
+		os.Exit(constants.StatusUnknown)
+	}
+
+	os.Exit(WorstStatus(results))
+}
+
+// ClusterResults returns the results of all nodes.
+func ClusterResults(nodeResults []NodeResult) []Result {
+	var results []Result
+	for _, nodeResult := range nodeResults {
+		results = append(results, nodeResult.Results...)
+	}
+	return results
+}
+
+// PrintClusterSummary writes a table with a row per node and check followed by the aggregate status.
+func PrintClusterSummary(w io.Writer, nodeResults []NodeResult) {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "NODE\tROLE\tCHECK\tSTATUS\tMESSAGE")
+	for _, nodeResult := range nodeResults {
+		for _, result := range nodeResult.Results {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", nodeResult.Node.IP, nodeResult.Node.Role, result.Name,
+				StatusName(result.Status), firstLine(resultMessage(result)))
+		}
+	}
+	tw.Flush()
+
+	fmt.Fprintf(w, "Overall status: %s\n", StatusName(WorstStatus(ClusterResults(nodeResults))))
+}
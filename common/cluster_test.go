@@ -0,0 +1,195 @@
+package common
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dcos/dcos-checks/constants"
+	"github.com/dcos/dcos-checks/fakecluster"
+	"github.com/dcos/dcos-go/dcos"
+	"github.com/pkg/errors"
+)
+
+func newClusterTestServer(t *testing.T) (*httptest.Server, URLFields) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/hosts/master.mesos", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"host": "master.mesos.", "ip": "10.0.0.1"}, {"host": "master.mesos.", "ip": "10.0.0.2"}]`))
+	})
+	mux.HandleFunc("/slaves", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"slaves": [{"hostname": "10.0.1.1"}, {"hostname": "10.0.2.1", "attributes": {"public_ip": "true"}}],
+			"recovered_slaves": [{"hostname": "10.0.1.2"}]}`))
+	})
+	ts := httptest.NewServer(mux)
+
+	u, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	host, portStr, err := net.SplitHostPort(u.Host)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return ts, URLFields{Host: host, Port: port}
+}
+
+func TestListNodes(t *testing.T) {
+	ts, urlopt := newClusterTestServer(t)
+	defer ts.Close()
+
+	cfg := &CLIConfigFlags{}
+	urlopt.Path = "/v1/hosts/master.mesos"
+	masters, err := ListMasters(context.TODO(), cfg, urlopt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(masters) != 2 || masters[1] != (Node{IP: "10.0.0.2", Role: dcos.RoleMaster}) {
+		t.Fatalf("unexpected masters %+v", masters)
+	}
+
+	urlopt.Path = "/slaves"
+	agents, err := ListAgents(context.TODO(), cfg, urlopt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Node{
+		{IP: "10.0.1.1", Role: dcos.RoleAgent},
+		{IP: "10.0.2.1", Role: dcos.RoleAgentPublic},
+		{IP: "10.0.1.2", Role: dcos.RoleAgent, Recovered: true},
+	}
+	if len(agents) != 3 || agents[0] != expected[0] || agents[1] != expected[1] || agents[2] != expected[2] {
+		t.Fatalf("expect agents %+v. Got %+v", expected, agents)
+	}
+}
+
+// TestListAgentsMesosResponse parses a sample of the Mesos /slaves endpoint.
+func TestListAgentsMesosResponse(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"slaves":[{"id":"529c3971-b5bb-4f9e-b817-bb32def0ede2-S1","hostname":"10.0.6.233","port":5051,"attributes":{"public_ip":"true"},"pid":"slave(1)@10.0.6.233:5051","registered_time":1496728541.24296,"resources":{"disk":35566.0,"mem":14021.0,"gpus":0.0,"cpus":4.0,"ports":"[1-21, 23-5050, 5052-32000]"},"used_resources":{"disk":0.0,"mem":0.0,"gpus":0.0,"cpus":0.0},"offered_resources":{"disk":0.0,"mem":0.0,"gpus":0.0,"cpus":0.0},"reserved_resources":{"slave_public":{"disk":35566.0,"mem":14021.0,"gpus":0.0,"cpus":4.0,"ports":"[1-21, 23-5050, 5052-32000]"}},"unreserved_resources":{"disk":0.0,"mem":0.0,"gpus":0.0,"cpus":0.0},"active":true,"version":"1.3.0","capabilities":["MULTI_ROLE"],"reserved_resources_full":{"slave_public":[{"name":"ports","type":"RANGES","ranges":{"range":[{"begin":1,"end":21},{"begin":23,"end":5050},{"begin":5052,"end":32000}]},"role":"slave_public"},{"name":"disk","type":"SCALAR","scalar":{"value":35566.0},"role":"slave_public"},{"name":"cpus","type":"SCALAR","scalar":{"value":4.0},"role":"slave_public"},{"name":"mem","type":"SCALAR","scalar":{"value":14021.0},"role":"slave_public"}]},"used_resources_full":[],"offered_resources_full":[]}]}`))
+	}))
+	defer ts.Close()
+
+	testurl, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	agents, err := ListAgents(context.TODO(), &CLIConfigFlags{}, URLFields{Host: testurl.Host, Path: "/slaves"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := Node{IP: "10.0.6.233", Role: dcos.RoleAgentPublic, MesosID: "529c3971-b5bb-4f9e-b817-bb32def0ede2-S1"}
+	if len(agents) != 1 || agents[0] != expected {
+		t.Fatalf("expect agents %+v. Got %+v", expected, agents)
+	}
+}
+
+func TestForEachNode(t *testing.T) {
+	nodes := make([]Node, 10)
+
+	var (
+		mu               sync.Mutex
+		running, maxRuns int
+	)
+	visited := make([]bool, len(nodes))
+	ForEachNode(nodes, 3, func(i int) {
+		mu.Lock()
+		running++
+		if running > maxRuns {
+			maxRuns = running
+		}
+		visited[i] = true
+		mu.Unlock()
+
+		time.Sleep(time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+	})
+
+	for i, ok := range visited {
+		if !ok {
+			t.Fatalf("expect node %d to be visited", i)
+		}
+	}
+
+	if maxRuns > 3 {
+		t.Fatalf("expect at most 3 concurrent calls. Got %d", maxRuns)
+	}
+}
+
+func TestRunClusterChecks(t *testing.T) {
+	nodes := []Node{
+		{IP: "10.0.0.1", Role: dcos.RoleMaster},
+		{IP: "10.0.1.1", Role: dcos.RoleAgent},
+		{IP: "10.0.2.1", Role: dcos.RoleAgentPublic},
+	}
+
+	tasks := func(node Node) ([]Task, error) {
+		switch node.Role {
+		case dcos.RoleAgent:
+			return []Task{{Name: "agent", Check: newFakeCheck("agent output", constants.StatusFailure, nil)}}, nil
+		case dcos.RoleAgentPublic:
+			return nil, errors.New("no checks")
+		}
+		return []Task{{Name: "master", Check: newFakeCheck("master output", constants.StatusOK, nil)}}, nil
+	}
+
+	nodeResults := RunClusterChecks(context.TODO(), &CLIConfigFlags{}, nodes, tasks, 2)
+	if len(nodeResults) != 3 {
+		t.Fatalf("expect a result per node. Got %+v", nodeResults)
+	}
+
+	for i, nodeResult := range nodeResults {
+		if nodeResult.Node != nodes[i] || len(nodeResult.Results) != 1 {
+			t.Fatalf("unexpected result for node %+v: %+v", nodes[i], nodeResult)
+		}
+
+		if nodeResult.Results[0].NodeIP != nodes[i].IP || nodeResult.Results[0].Role != nodes[i].Role {
+			t.Fatalf("expect result to have node %+v. Got %+v", nodes[i], nodeResult.Results[0])
+		}
+	}
+
+	if nodeResults[0].Results[0].Status != constants.StatusOK || nodeResults[1].Results[0].Status != constants.StatusFailure {
+		t.Fatalf("unexpected results %+v", nodeResults)
+	}
+
+	if nodeResults[2].Results[0].Status != constants.StatusUnknown || nodeResults[2].Results[0].Err == nil {
+		t.Fatalf("expect an unknown status if tasks cannot be created. Got %+v", nodeResults[2].Results[0])
+	}
+
+	if status := WorstStatus(ClusterResults(nodeResults)); status != constants.StatusUnknown {
+		t.Fatalf("expect worst status unknown. Got %d", status)
+	}
+}
+
+func TestDiscoverNodes(t *testing.T) {
+	cluster := fakecluster.New(fakecluster.Healthy())
+	defer cluster.Close()
+
+	nodes, err := DiscoverNodes(cluster.Context(context.TODO()), &CLIConfigFlags{}, dcos.DNSRecordLeader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(nodes) != 6 || nodes[0].Role != dcos.RoleMaster || nodes[5] != (Node{IP: "10.0.2.1", Role: dcos.RoleAgentPublic, MesosID: "agent-5"}) {
+		t.Fatalf("unexpected nodes %+v", nodes)
+	}
+}
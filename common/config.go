@@ -3,6 +3,7 @@ package common
 import (
 	"net"
 	"net/http"
+	"time"
 
 	"github.com/dcos/dcos-checks/client"
 	"github.com/pkg/errors"
@@ -31,11 +32,60 @@ type CLIConfigFlags struct {
 	// defined in "github.com/dcos/dcos-go/dcos" package.
 	Role string
 
+	// RolesDir is a directory with role marker files used to detect Role if it is not set.
+	RolesDir string
+
 	// DetectIP is a path to detect_ip script. Usually must be /opt/mesosphere/bin/detect_ip
 	DetectIP string
 
 	// NodeIPStr describes an IP address. This option will override the output of DetectIP.
 	NodeIPStr string
+
+	// Output is a format of check results. Valid formats are: text, json, yaml, prometheus.
+	Output string
+
+	// PrometheusTextfile is a path to a file the check metrics are written to
+	// for node_exporter textfile collector.
+	PrometheusTextfile string
+
+	// Timeout overrides the default timeout of every check if set.
+	Timeout time.Duration
+
+	// Retries overrides the number of times a check is retried on error if set.
+	Retries int
+
+	// RetryBackoff overrides the delay before the first retry if set.
+	RetryBackoff time.Duration
+
+	// JUnitWarningsAsFailures reports warnings as failures instead of skipped test cases in JUnit output.
+	JUnitWarningsAsFailures bool
+
+	// History enables recording check results in HistoryDir.
+	History bool
+
+	// HistoryDir is a directory the check history is stored in.
+	HistoryDir string
+
+	// HistorySize is a maximum number of results kept per check.
+	HistorySize int
+
+	// EscalateAfter escalates a warning to a failure if a check returned a non-OK status
+	// for the given number of consecutive runs. Zero disables escalation.
+	EscalateAfter int
+
+	// FlapThreshold marks a check as flapping if its status changed at least the given number
+	// of times within the latest FlapWindow runs. Zero disables flap detection.
+	FlapThreshold int
+	FlapWindow    int
+
+	// Explain prints the HTTP requests, commands and files the checks use instead of executing them.
+	Explain bool
+
+	// Cluster enables running node checks against every node of the cluster.
+	Cluster bool
+
+	// ClusterWorkers is a maximum number of nodes checked concurrently in cluster mode.
+	ClusterWorkers int
 }
 
 // IP returns a valid IP address. If NodeIPStr is set, it will be used. Otherwise DetectIP will be executed
@@ -0,0 +1,369 @@
+package common
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dcos/dcos-go/dcos"
+	"github.com/pkg/errors"
+	"github.com/spf13/cast"
+	"github.com/spf13/pflag"
+)
+
+// ConfigError describes an invalid config key.
+type ConfigError struct {
+	// Key is a dot separated path to the offending key, e.g. suites.poststart.checks.version.roles.
+	Key string
+
+	// Message describes the problem.
+	Message string
+}
+
+// Error implements the error interface.
+func (e ConfigError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Key, e.Message)
+}
+
+// knownRoles is a list of valid DC/OS roles.
+var knownRoles = []string{dcos.RoleMaster, dcos.RoleAgent, dcos.RoleAgentPublic}
+
+// configValueValidators validate the values of top level config keys after their type is checked.
+var configValueValidators = map[string]func(value string) error{
+	"role":       validateRole,
+	"iam-config": validateFilePath,
+	"ca-cert":    validateFilePath,
+	"detect-ip":  validateFilePath,
+	"node-ip":    validateIP,
+	"output":     ValidateOutputFormat,
+}
+
+// suiteKeys and suiteCheckKeys are the keys allowed in a suite and in a suite check.
+var (
+	suiteKeys      = []string{"description", "checks"}
+	suiteCheckKeys = []string{"check", "roles", "timeout", "retries", "backoff", "args", "params"}
+)
+
+// ValidateConfig validates the settings read from a config file. Every top level key must be
+// a flag in flags, "suites" or "params", and its value must be valid for the flag type. Suites
+// and params must reference registered checks and their parameters. The returned errors are sorted by key.
+func ValidateConfig(settings map[string]interface{}, flags *pflag.FlagSet) []ConfigError {
+	var errs []ConfigError
+	for key, value := range settings {
+		switch key {
+		case "suites":
+			errs = append(errs, validateSuites(key, value)...)
+			continue
+		case "params":
+			errs = append(errs, validateConfigParams(key, value)...)
+			continue
+		}
+
+		flag := flags.Lookup(key)
+		if flag == nil {
+			errs = append(errs, ConfigError{Key: key, Message: "unknown key"})
+			continue
+		}
+
+		if err := validateConfigValue(key, flag.Value.Type(), value); err != nil {
+			errs = append(errs, ConfigError{Key: key, Message: err.Error()})
+		}
+	}
+
+	sort.Slice(errs, func(i, j int) bool {
+		return errs[i].Key < errs[j].Key
+	})
+	return errs
+}
+
+// validateConfigValue checks the value has the given flag type and passes the key validator if any.
+func validateConfigValue(key, flagType string, value interface{}) error {
+	var err error
+	switch flagType {
+	case "bool":
+		_, err = cast.ToBoolE(value)
+	case "int":
+		err = validateNonNegativeInt(value)
+	case "duration":
+		err = validateDuration(value)
+	case "stringSlice":
+		_, err = cast.ToStringSliceE(value)
+	default:
+		if !isScalar(value) {
+			err = errors.Errorf("expect a string, got %T", value)
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if validate, ok := configValueValidators[key]; ok {
+		return validate(cast.ToString(value))
+	}
+	return nil
+}
+
+func validateSuites(key string, value interface{}) []ConfigError {
+	suites, err := cast.ToStringMapE(value)
+	if err != nil {
+		return []ConfigError{{Key: key, Message: "expect a map of suites"}}
+	}
+
+	var errs []ConfigError
+	for name, suite := range suites {
+		errs = append(errs, validateSuite(key+"."+name, suite)...)
+	}
+	return errs
+}
+
+func validateSuite(key string, value interface{}) []ConfigError {
+	suite, err := cast.ToStringMapE(value)
+	if err != nil {
+		return []ConfigError{{Key: key, Message: "expect a map with description and checks"}}
+	}
+
+	errs := unknownKeys(key, suite, suiteKeys)
+	if description, ok := suite["description"]; ok && !isScalar(description) {
+		errs = append(errs, ConfigError{Key: key + ".description", Message: "expect a string"})
+	}
+
+	checks, err := cast.ToStringMapE(suite["checks"])
+	if err != nil || len(checks) == 0 {
+		return append(errs, ConfigError{Key: key + ".checks", Message: "expect a non-empty map of checks"})
+	}
+
+	for entry, check := range checks {
+		errs = append(errs, validateSuiteCheck(key+".checks."+entry, entry, check)...)
+	}
+	return errs
+}
+
+func validateSuiteCheck(key, entry string, value interface{}) []ConfigError {
+	var check map[string]interface{}
+	if value != nil {
+		var err error
+		if check, err = cast.ToStringMapE(value); err != nil {
+			return []ConfigError{{Key: key, Message: "expect a map of check options"}}
+		}
+	}
+
+	errs := unknownKeys(key, check, suiteCheckKeys)
+	addErr := func(option string, err error) {
+		if err != nil {
+			errs = append(errs, ConfigError{Key: key + "." + option, Message: err.Error()})
+		}
+	}
+
+	if roles, ok := check["roles"]; ok {
+		addErr("roles", validateRoles(roles))
+	}
+	if timeout, ok := check["timeout"]; ok {
+		addErr("timeout", validateDuration(timeout))
+	}
+	if backoff, ok := check["backoff"]; ok {
+		addErr("backoff", validateDuration(backoff))
+	}
+	if retries, ok := check["retries"]; ok {
+		addErr("retries", validateNonNegativeInt(retries))
+	}
+	if args, ok := check["args"]; ok {
+		_, err := cast.ToStringSliceE(args)
+		addErr("args", err)
+	}
+
+	name := entry
+	if c, ok := check["check"]; ok {
+		name = cast.ToString(c)
+	}
+
+	spec, ok := LookupCheck(name)
+	if !ok {
+		// report an unknown entry name on the entry itself.
+		if _, set := check["check"]; !set {
+			return append(errs, ConfigError{Key: key, Message: fmt.Sprintf("unknown check %s", name)})
+		}
+		addErr("check", errors.Errorf("unknown check %s", name))
+		return errs
+	}
+
+	params, ok := check["params"]
+	if !ok {
+		return errs
+	}
+
+	paramsMap, err := cast.ToStringMapE(params)
+	if err != nil {
+		addErr("params", errors.New("expect a map of check parameters"))
+		return errs
+	}
+
+	return append(errs, validateParams(key+".params", spec, paramsMap)...)
+}
+
+// validateConfigParams validates the params key mapping check names to their parameters.
+func validateConfigParams(key string, value interface{}) []ConfigError {
+	checks, err := cast.ToStringMapE(value)
+	if err != nil {
+		return []ConfigError{{Key: key, Message: "expect a map of checks"}}
+	}
+
+	var errs []ConfigError
+	for name, params := range checks {
+		spec, ok := LookupCheck(name)
+		if !ok {
+			errs = append(errs, ConfigError{Key: key + "." + name, Message: fmt.Sprintf("unknown check %s", name)})
+			continue
+		}
+
+		paramsMap, err := cast.ToStringMapE(params)
+		if err != nil {
+			errs = append(errs, ConfigError{Key: key + "." + name, Message: "expect a map of check parameters"})
+			continue
+		}
+		errs = append(errs, validateParams(key+"."+name, spec, paramsMap)...)
+	}
+	return errs
+}
+
+// validateParams returns an error for every unknown or invalid parameter of the check.
+func validateParams(key string, spec CheckSpec, params map[string]interface{}) []ConfigError {
+	var errs []ConfigError
+	flags := spec.FlagSet()
+	for param, value := range params {
+		if flags.Lookup(param) == nil {
+			errs = append(errs, ConfigError{
+				Key:     key + "." + param,
+				Message: fmt.Sprintf("check %s has no parameter %s", spec.Name, param),
+			})
+			continue
+		}
+
+		if err := flags.Set(param, paramValue(value)); err != nil {
+			errs = append(errs, ConfigError{Key: key + "." + param, Message: err.Error()})
+		}
+	}
+	return errs
+}
+
+// unknownKeys returns an error for every key of m not in allowed.
+func unknownKeys(key string, m map[string]interface{}, allowed []string) []ConfigError {
+	var errs []ConfigError
+	for k := range m {
+		if !containsString(allowed, k) {
+			errs = append(errs, ConfigError{
+				Key:     key + "." + k,
+				Message: fmt.Sprintf("unknown key, expect one of: %s", strings.Join(allowed, ", ")),
+			})
+		}
+	}
+	return errs
+}
+
+func validateRole(role string) error {
+	if role == "" || containsString(knownRoles, role) {
+		return nil
+	}
+	return errors.Errorf("unknown role %s, expect one of: %s", role, strings.Join(knownRoles, ", "))
+}
+
+func validateRoles(value interface{}) error {
+	roles, err := cast.ToStringSliceE(value)
+	if err != nil {
+		return err
+	}
+
+	for _, role := range roles {
+		if role == "" {
+			return errors.New("role must not be empty")
+		}
+		if err := validateRole(role); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateFilePath(path string) error {
+	if path == "" {
+		return nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		return errors.Errorf("%s is a directory", path)
+	}
+	return nil
+}
+
+func validateIP(ip string) error {
+	if ip == "" || net.ParseIP(ip) != nil {
+		return nil
+	}
+	return errors.Errorf("invalid IP address %s", ip)
+}
+
+func validateDuration(value interface{}) error {
+	d, err := cast.ToDurationE(value)
+	if err != nil {
+		return err
+	}
+
+	if d < 0 {
+		return errors.Errorf("duration %s must not be negative", d)
+	}
+	return nil
+}
+
+func validateNonNegativeInt(value interface{}) error {
+	var n int64
+	switch v := value.(type) {
+	case int:
+		n = int64(v)
+	case int64:
+		n = v
+	case float64:
+		// JSON numbers are decoded as floats.
+		if v != math.Trunc(v) {
+			return errors.Errorf("expect an integer, got %v", v)
+		}
+		n = int64(v)
+	case string:
+		var err error
+		if n, err = strconv.ParseInt(strings.TrimSpace(v), 10, 64); err != nil {
+			return errors.Errorf("invalid integer %q", v)
+		}
+	default:
+		return errors.Errorf("expect an integer, got %T", value)
+	}
+
+	if n < 0 {
+		return errors.Errorf("%d must not be negative", n)
+	}
+	return nil
+}
+
+func isScalar(value interface{}) bool {
+	switch value.(type) {
+	case map[string]interface{}, map[interface{}]interface{}, []interface{}, []string:
+		return false
+	}
+	return true
+}
+
+func containsString(items []string, s string) bool {
+	for _, item := range items {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
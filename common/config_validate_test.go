@@ -0,0 +1,147 @@
+package common
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+func validateConfigFlags() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.Bool("force-tls", false, "")
+	flags.String("role", "", "")
+	flags.String("ca-cert", "", "")
+	flags.String("detect-ip", "", "")
+	flags.String("node-ip", "", "")
+	flags.String("output", OutputText, "")
+	flags.Int("retries", 0, "")
+	flags.Duration("timeout", 0, "")
+	flags.StringSlice("plugin-dir", nil, "")
+	return flags
+}
+
+func TestValidateConfigValid(t *testing.T) {
+	settings := map[string]interface{}{
+		"force-tls":  "true",
+		"role":       "agent_public",
+		"detect-ip":  "fixture/detect_ip",
+		"node-ip":    "10.0.0.1",
+		"output":     "json",
+		"retries":    float64(2),
+		"timeout":    "10s",
+		"plugin-dir": []interface{}{"/tmp"},
+		"params": map[string]interface{}{
+			"test-suite-check": map[string]interface{}{
+				"items": []interface{}{"a"},
+			},
+		},
+		"suites": map[string]interface{}{
+			"poststart": map[string]interface{}{
+				"description": "checks executed after start",
+				"checks": map[string]interface{}{
+					"test-suite-check": map[string]interface{}{
+						"timeout": "5s",
+						"roles":   []interface{}{"master"},
+						"params": map[string]interface{}{
+							"items": []interface{}{"a", "b"},
+						},
+					},
+					"alias": map[string]interface{}{
+						"check":   "test-suite-check",
+						"retries": 1,
+						"backoff": time.Second,
+					},
+				},
+			},
+		},
+	}
+
+	if errs := ValidateConfig(settings, validateConfigFlags()); len(errs) != 0 {
+		t.Fatalf("expect no errors. Got %v", errs)
+	}
+}
+
+func TestValidateConfigErrors(t *testing.T) {
+	settings := map[string]interface{}{
+		"unknown":   "value",
+		"force-tls": "maybe",
+		"role":      "public-agent",
+		"ca-cert":   "fixture/missing.crt",
+		"detect-ip": "fixture",
+		"node-ip":   "10.0.0",
+		"output":    "xml",
+		"retries":   -1,
+		"timeout":   "soon",
+		"params": map[string]interface{}{
+			"missing": map[string]interface{}{},
+			"test-suite-check": map[string]interface{}{
+				"unknown": "value",
+			},
+		},
+		"suites": map[string]interface{}{
+			"empty": map[string]interface{}{},
+			"poststart": map[string]interface{}{
+				"checks": map[string]interface{}{
+					"missing": nil,
+					"alias": map[string]interface{}{
+						"check": "missing",
+					},
+					"test-suite-check": map[string]interface{}{
+						"roles":   []interface{}{"slave"},
+						"timeout": "-1s",
+						"retries": 1.5,
+						"extra":   true,
+						"params": map[string]interface{}{
+							"unknown": "value",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	var keys []string
+	for _, err := range ValidateConfig(settings, validateConfigFlags()) {
+		keys = append(keys, err.Key)
+	}
+
+	expected := []string{
+		"ca-cert",
+		"detect-ip",
+		"force-tls",
+		"node-ip",
+		"output",
+		"params.missing",
+		"params.test-suite-check.unknown",
+		"retries",
+		"role",
+		"suites.empty.checks",
+		"suites.poststart.checks.alias.check",
+		"suites.poststart.checks.missing",
+		"suites.poststart.checks.test-suite-check.extra",
+		"suites.poststart.checks.test-suite-check.params.unknown",
+		"suites.poststart.checks.test-suite-check.retries",
+		"suites.poststart.checks.test-suite-check.roles",
+		"suites.poststart.checks.test-suite-check.timeout",
+		"timeout",
+		"unknown",
+	}
+
+	if !reflect.DeepEqual(keys, expected) {
+		t.Fatalf("expect errors for keys %v. Got %v", expected, keys)
+	}
+}
+
+func TestConfigErrorMessage(t *testing.T) {
+	errs := ValidateConfig(map[string]interface{}{"role": "slave"}, validateConfigFlags())
+	if len(errs) != 1 {
+		t.Fatalf("expect 1 error. Got %v", errs)
+	}
+
+	expected := "role: unknown role slave, expect one of: master, agent, agent_public"
+	if errs[0].Error() != expected {
+		t.Fatalf("expect error %q. Got %q", expected, errs[0].Error())
+	}
+}
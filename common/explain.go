@@ -0,0 +1,234 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/dcos/dcos-checks/constants"
+	"gopkg.in/yaml.v2"
+)
+
+// Action kinds.
+const (
+	// ActionHTTP is an HTTP request.
+	ActionHTTP = "http"
+
+	// ActionExec is an executed command.
+	ActionExec = "exec"
+
+	// ActionFile is an inspected file or directory.
+	ActionFile = "file"
+
+	// ActionSyscall is a system call.
+	ActionSyscall = "syscall"
+)
+
+// Action describes an operation a check performs when it is executed.
+type Action struct {
+	Kind        string `json:"kind" yaml:"kind"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+
+	// Method, Scheme, Host, Port, Path and Auth describe an HTTP request. Auth is
+	// "none" or "iam" if requests are authenticated with the IAM config.
+	Method string `json:"method,omitempty" yaml:"method,omitempty"`
+	Scheme string `json:"scheme,omitempty" yaml:"scheme,omitempty"`
+	Host   string `json:"host,omitempty" yaml:"host,omitempty"`
+	Port   int    `json:"port,omitempty" yaml:"port,omitempty"`
+	Path   string `json:"path,omitempty" yaml:"path,omitempty"`
+	Auth   string `json:"auth,omitempty" yaml:"auth,omitempty"`
+
+	// Command is an executed command with its arguments.
+	Command []string `json:"command,omitempty" yaml:"command,omitempty"`
+
+	// File is a path to an inspected file or directory.
+	File string `json:"file,omitempty" yaml:"file,omitempty"`
+
+	// Syscall is a name of a system call.
+	Syscall string `json:"syscall,omitempty" yaml:"syscall,omitempty"`
+}
+
+// String returns a one line description of the action.
+func (a Action) String() string {
+	var s string
+	switch a.Kind {
+	case ActionHTTP:
+		host := net.JoinHostPort(a.Host, strconv.Itoa(a.Port))
+		s = fmt.Sprintf("%s %s://%s%s (auth: %s)", a.Method, a.Scheme, host, a.Path, a.Auth)
+	case ActionExec:
+		s = "exec " + strings.Join(a.Command, " ")
+	case ActionFile:
+		s = "file " + a.File
+	case ActionSyscall:
+		s = "syscall " + a.Syscall
+	default:
+		s = a.Kind
+	}
+
+	if a.Description != "" {
+		s += " - " + a.Description
+	}
+	return s
+}
+
+// Explainer is an optional interface of a check which describes the HTTP requests, commands
+// and files the check uses. Explain resolves them without performing them.
+type Explainer interface {
+	Explain(ctx context.Context, cfg *CLIConfigFlags) ([]Action, error)
+}
+
+// Explanation is a machine readable description of the actions of a check.
+type Explanation struct {
+	Name    string   `json:"name" yaml:"name"`
+	ID      string   `json:"id" yaml:"id"`
+	Actions []Action `json:"actions" yaml:"actions"`
+	Error   string   `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// HTTPAction returns an action for an HTTP request to u. The port defaults to the scheme port.
+func HTTPAction(cfg *CLIConfigFlags, method string, u *url.URL, description string) Action {
+	port, err := strconv.Atoi(u.Port())
+	if err != nil {
+		port = 80
+		if u.Scheme == constants.HTTPSScheme {
+			port = 443
+		}
+	}
+
+	return Action{
+		Kind:        ActionHTTP,
+		Description: description,
+		Method:      method,
+		Scheme:      u.Scheme,
+		Host:        u.Hostname(),
+		Port:        port,
+		Path:        u.Path,
+		Auth:        authMode(cfg),
+	}
+}
+
+// NodeIPPlaceholder is the host of explained requests to the local node if --node-ip is not set.
+const NodeIPPlaceholder = "<node ip>"
+
+// ExplainNodeIP returns the host of requests to the local node and the actions resolving it.
+// Explain does not execute detect_ip, so the host is NodeIPPlaceholder unless --node-ip is set.
+func ExplainNodeIP(cfg *CLIConfigFlags) (string, []Action) {
+	if cfg.NodeIPStr != "" {
+		return cfg.NodeIPStr, nil
+	}
+
+	return NodeIPPlaceholder, []Action{{
+		Kind:        ActionExec,
+		Description: "detect the node IP address",
+		Command:     []string{cfg.DetectIP},
+	}}
+}
+
+// ExplainHTTPRequest returns an action for the request HTTPRequest makes with the given url fields.
+// If the host is not set, the request is made to the local node and the host is resolved
+// by ExplainNodeIP.
+func ExplainHTTPRequest(cfg *CLIConfigFlags, urlOptions URLFields, description string) (Action, error) {
+	if urlOptions.Host == "" {
+		urlOptions.Host, _ = ExplainNodeIP(cfg)
+	}
+
+	url, err := GetURL(nil, cfg, urlOptions)
+	if err != nil {
+		return Action{}, err
+	}
+	return HTTPAction(cfg, "GET", url, description), nil
+}
+
+// authMode returns the authentication mode of HTTP requests.
+func authMode(cfg *CLIConfigFlags) string {
+	if cfg.IAMConfig != "" {
+		return "iam"
+	}
+	return "none"
+}
+
+// ExplainTasks returns an explanation for each task. The checks are not executed.
+func ExplainTasks(ctx context.Context, cfg *CLIConfigFlags, tasks []Task) []Explanation {
+	ctx = withRunContext(ctx, cfg)
+
+	explanations := make([]Explanation, 0, len(tasks))
+	for _, task := range tasks {
+		explanation := Explanation{
+			Name: task.DisplayName(),
+			ID:   task.Check.ID(),
+		}
+
+		explainer, ok := task.Check.(Explainer)
+		if !ok {
+			explanation.Error = "the check does not describe its actions"
+			explanations = append(explanations, explanation)
+			continue
+		}
+
+		actions, err := explainer.Explain(ctx, cfg)
+		if err != nil {
+			explanation.Error = err.Error()
+		}
+		explanation.Actions = actions
+		explanations = append(explanations, explanation)
+	}
+	return explanations
+}
+
+// WriteExplanations writes the explanations to w as JSON or YAML documents if set by
+// the output format in cfg, or as text otherwise.
+func WriteExplanations(w io.Writer, cfg *CLIConfigFlags, explanations []Explanation) error {
+	switch cfg.Output {
+	case OutputJSON:
+		encoder := json.NewEncoder(w)
+		for _, explanation := range explanations {
+			if err := encoder.Encode(explanation); err != nil {
+				return err
+			}
+		}
+	case OutputYAML:
+		for _, explanation := range explanations {
+			body, err := yaml.Marshal(explanation)
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(w, "---\n%s", body); err != nil {
+				return err
+			}
+		}
+	default:
+		for _, explanation := range explanations {
+			fmt.Fprintf(w, "%s: %s\n", explanation.Name, explanation.ID)
+			for _, action := range explanation.Actions {
+				fmt.Fprintf(w, "  %s\n", action)
+			}
+			if explanation.Error != "" {
+				fmt.Fprintf(w, "  error: %s\n", explanation.Error)
+			}
+		}
+	}
+	return nil
+}
+
+// ExplainTasksAndExit prints the actions of the tasks checks and exits with
+// StatusUnknown if any of the checks could not be explained.
+func ExplainTasksAndExit(ctx context.Context, tasks []Task) {
+	explanations := ExplainTasks(ctx, DCOSConfig, tasks)
+	if err := WriteExplanations(os.Stdout, DCOSConfig, explanations); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing explanations: %s\n", err)
+		os.Exit(constants.StatusUnknown)
+	}
+
+	for _, explanation := range explanations {
+		if explanation.Error != "" {
+			os.Exit(constants.StatusUnknown)
+		}
+	}
+	os.Exit(constants.StatusOK)
+}
@@ -0,0 +1,125 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/dcos/dcos-checks/constants"
+)
+
+type fakeExplainer struct {
+	fakeCheck
+	actions []Action
+}
+
+func (f fakeExplainer) Explain(context.Context, *CLIConfigFlags) ([]Action, error) {
+	return f.actions, nil
+}
+
+func TestActionString(t *testing.T) {
+	u, err := url.Parse("https://10.0.0.1/system/health/v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, testCase := range []struct {
+		action   Action
+		expected string
+	}{
+		{
+			action:   HTTPAction(&CLIConfigFlags{IAMConfig: "iam.json"}, "GET", u, "health"),
+			expected: "GET https://10.0.0.1:443/system/health/v1 (auth: iam) - health",
+		},
+		{
+			action:   Action{Kind: ActionExec, Command: []string{"bash", "-c", "command -v curl"}},
+			expected: "exec bash -c command -v curl",
+		},
+		{
+			action:   Action{Kind: ActionFile, File: "/var/log/journal"},
+			expected: "file /var/log/journal",
+		},
+		{
+			action:   Action{Kind: ActionSyscall, Syscall: "adjtimex"},
+			expected: "syscall adjtimex",
+		},
+	} {
+		if s := testCase.action.String(); s != testCase.expected {
+			t.Fatalf("expect %q. Got %q", testCase.expected, s)
+		}
+	}
+}
+
+func TestExplainHTTPRequest(t *testing.T) {
+	for _, testCase := range []struct {
+		cfg      *CLIConfigFlags
+		expected string
+	}{
+		{&CLIConfigFlags{NodeIPStr: "10.0.0.1"}, "GET http://10.0.0.1:5050/state (auth: none)"},
+		{&CLIConfigFlags{DetectIP: "/bin/false"}, "GET http://<node ip>:5050/state (auth: none)"},
+	} {
+		action, err := ExplainHTTPRequest(testCase.cfg, URLFields{Port: 5050, Path: "/state"}, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if action.String() != testCase.expected {
+			t.Fatalf("expect %q. Got %q", testCase.expected, action)
+		}
+	}
+}
+
+func TestExplainNodeIP(t *testing.T) {
+	host, actions := ExplainNodeIP(&CLIConfigFlags{DetectIP: "/opt/mesosphere/bin/detect_ip"})
+	if host != NodeIPPlaceholder || len(actions) != 1 || actions[0].String() != "exec /opt/mesosphere/bin/detect_ip - detect the node IP address" {
+		t.Fatalf("expect detect_ip to be explained. Got %s, %v", host, actions)
+	}
+
+	host, actions = ExplainNodeIP(&CLIConfigFlags{NodeIPStr: "10.0.0.1", DetectIP: "/opt/mesosphere/bin/detect_ip"})
+	if host != "10.0.0.1" || len(actions) != 0 {
+		t.Fatalf("expect --node-ip to be used. Got %s, %v", host, actions)
+	}
+}
+
+func TestExplainTasks(t *testing.T) {
+	tasks := []Task{
+		{
+			Name: "explained",
+			Check: fakeExplainer{
+				actions: []Action{{Kind: ActionSyscall, Syscall: "adjtimex"}},
+			},
+		},
+		{
+			Name:  "unexplained",
+			Check: newFakeCheck("", constants.StatusOK, nil),
+		},
+	}
+
+	explanations := ExplainTasks(context.TODO(), &CLIConfigFlags{}, tasks)
+	if len(explanations) != 2 {
+		t.Fatalf("expect 2 explanations. Got %v", explanations)
+	}
+
+	if len(explanations[0].Actions) != 1 || explanations[0].Error != "" {
+		t.Fatalf("expect an action of the explained check. Got %+v", explanations[0])
+	}
+
+	if explanations[1].Error == "" {
+		t.Fatalf("expect an error for a check without explanation. Got %+v", explanations[1])
+	}
+
+	var buf bytes.Buffer
+	if err := WriteExplanations(&buf, &CLIConfigFlags{Output: OutputText}, explanations); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `explained: fakeCheck
+  syscall adjtimex
+unexplained: fakeCheck
+  error: the check does not describe its actions
+`
+	if buf.String() != expected {
+		t.Fatalf("expect output:\n%s\nGot:\n%s", expected, buf.String())
+	}
+}
@@ -0,0 +1,213 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dcos/dcos-checks/constants"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultHistoryDir is a directory the check history is stored in by default.
+const DefaultHistoryDir = "/var/lib/dcos/dcos-checks"
+
+// HistoryEntry is a single check result stored in the history.
+type HistoryEntry struct {
+	Time     time.Time `json:"time" yaml:"time"`
+	Status   int       `json:"status" yaml:"status"`
+	Output   string    `json:"output,omitempty" yaml:"output,omitempty"`
+	Error    string    `json:"error,omitempty" yaml:"error,omitempty"`
+	Duration float64   `json:"duration_seconds" yaml:"duration_seconds"`
+}
+
+// History stores the latest results of each check in a JSON file per check.
+type History struct {
+	// Dir is a directory the history files are stored in.
+	Dir string
+
+	// Size is a maximum number of entries kept per check. Zero means no limit.
+	Size int
+}
+
+// Load returns the stored entries of the check with the given key, oldest first.
+// An empty history is returned if the check was never recorded.
+func (h History) Load(key string) ([]HistoryEntry, error) {
+	body, err := ioutil.ReadFile(h.path(key))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read history of %s", key)
+	}
+
+	var entries []HistoryEntry
+	if err := json.Unmarshal(body, &entries); err != nil {
+		return nil, errors.Wrapf(err, "unable to unmarshal history of %s", key)
+	}
+	return entries, nil
+}
+
+// Append adds an entry to the history of the check with the given key, dropping the oldest
+// entries above the history size, and returns the updated history. The history of the key is
+// locked while it is updated, so concurrent runs, e.g. by cron and checks serve, do not lose entries.
+func (h History) Append(key string, entry HistoryEntry) ([]HistoryEntry, error) {
+	if err := os.MkdirAll(h.Dir, 0755); err != nil {
+		return nil, errors.Wrapf(err, "unable to create history directory %s", h.Dir)
+	}
+
+	// the history file is replaced on every update, a separate lock file is locked instead.
+	unlock, err := lockFile(filepath.Join(h.Dir, key+".lock"))
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to lock history of %s", key)
+	}
+	defer unlock()
+
+	entries, err := h.Load(key)
+	if err != nil {
+		return nil, err
+	}
+
+	entries = append(entries, entry)
+	if h.Size > 0 && len(entries) > h.Size {
+		entries = entries[len(entries)-h.Size:]
+	}
+
+	body, err := json.Marshal(entries)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to marshal history of %s", key)
+	}
+
+	// write to a temporary file first, so a concurrent reader never sees a partially written history.
+	f, err := ioutil.TempFile(h.Dir, key)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create a temporary file")
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(body); err != nil {
+		f.Close()
+		return nil, errors.Wrapf(err, "unable to write history of %s", key)
+	}
+
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := os.Chmod(f.Name(), 0644); err != nil {
+		return nil, err
+	}
+
+	return entries, os.Rename(f.Name(), h.path(key))
+}
+
+func (h History) path(key string) string {
+	return filepath.Join(h.Dir, key+".json")
+}
+
+// HistoryKey returns a key the history of a check is stored under. Results of cluster
+// runs are stored per node.
+func HistoryKey(name, nodeIP string) string {
+	if nodeIP == "" {
+		return escapeHistoryKey(name)
+	}
+
+	// the separator is escaped in both parts, so distinct names and nodes never share a key.
+	return escapeHistoryKey(nodeIP) + "_" + escapeHistoryKey(name)
+}
+
+// escapeHistoryKey escapes a part of a history key used as a file name. Letters, digits, '-'
+// and '.' are kept, any other byte is escaped as %XX, so the escaping is reversible.
+func escapeHistoryKey(s string) string {
+	var b bytes.Buffer
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '.' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+// newHistoryEntry returns a history entry for the result.
+func newHistoryEntry(result Result) HistoryEntry {
+	entry := HistoryEntry{
+		Time:     result.Start,
+		Status:   result.Status,
+		Output:   result.Output,
+		Duration: result.Duration.Seconds(),
+	}
+
+	if result.Err != nil {
+		entry.Error = result.Err.Error()
+	}
+	return entry
+}
+
+// ApplyHistory records the results in the history if enabled in cfg, escalates warnings
+// returned for cfg.EscalateAfter consecutive runs to failures and marks flapping checks.
+// The history stores the original statuses returned by the checks.
+func ApplyHistory(cfg *CLIConfigFlags, results []Result) []Result {
+	if cfg == nil || !cfg.History {
+		return results
+	}
+
+	history := History{Dir: cfg.HistoryDir, Size: cfg.HistorySize}
+	for i, result := range results {
+		entries, err := history.Append(HistoryKey(resultName(result), result.NodeIP), newHistoryEntry(result))
+		if err != nil {
+			logrus.Errorf("Unable to record history: %s", err)
+			continue
+		}
+
+		results[i] = applyHistoryPolicy(cfg, result, entries)
+	}
+	return results
+}
+
+// applyHistoryPolicy updates the result based on the history entries, including the result itself.
+func applyHistoryPolicy(cfg *CLIConfigFlags, result Result, entries []HistoryEntry) Result {
+	if cfg.EscalateAfter > 0 && normalizeStatus(result.Status) == constants.StatusWarning &&
+		consecutiveNonOK(entries) >= cfg.EscalateAfter {
+		result.Status = constants.StatusFailure
+		result.Escalated = true
+	}
+
+	if cfg.FlapThreshold > 0 && statusChanges(entries, cfg.FlapWindow) >= cfg.FlapThreshold {
+		result.Flapping = true
+	}
+	return result
+}
+
+// consecutiveNonOK returns a number of the latest entries with a non-OK status.
+func consecutiveNonOK(entries []HistoryEntry) int {
+	count := 0
+	for i := len(entries) - 1; i >= 0 && entries[i].Status != constants.StatusOK; i-- {
+		count++
+	}
+	return count
+}
+
+// statusChanges returns a number of status changes within the latest window entries.
+// Zero window means all entries.
+func statusChanges(entries []HistoryEntry, window int) int {
+	if window > 0 && len(entries) > window {
+		entries = entries[len(entries)-window:]
+	}
+
+	changes := 0
+	for i := 1; i < len(entries); i++ {
+		if normalizeStatus(entries[i].Status) != normalizeStatus(entries[i-1].Status) {
+			changes++
+		}
+	}
+	return changes
+}
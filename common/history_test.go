@@ -0,0 +1,143 @@
+package common
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dcos/dcos-checks/constants"
+)
+
+func TestHistoryAppend(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dcos-checks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	history := History{Dir: dir, Size: 3}
+	entries, err := history.Load("time")
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("expect empty history. Got %+v, %v", entries, err)
+	}
+
+	for i := 0; i < 5; i++ {
+		if _, err := history.Append("time", HistoryEntry{Status: i % 2, Output: "run"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err = history.Load("time")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 3 || entries[0].Status != constants.StatusOK || entries[2].Status != constants.StatusOK {
+		t.Fatalf("expect the 3 latest entries. Got %+v", entries)
+	}
+}
+
+func TestHistoryAppendConcurrent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dcos-checks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	history := History{Dir: dir}
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := history.Append("time", HistoryEntry{Status: constants.StatusOK}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	entries, err := history.Load("time")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 20 {
+		t.Fatalf("expect 20 entries. Got %d", len(entries))
+	}
+}
+
+func TestHistoryKey(t *testing.T) {
+	for _, testCase := range []struct {
+		name     string
+		nodeIP   string
+		expected string
+	}{
+		{"../ip", "", "..%2Fip"},
+		{"components", "10.0.0.1", "10.0.0.1_components"},
+		{"b_c", "a", "a_b%5Fc"},
+		{"c", "a_b", "a%5Fb_c"},
+		{"a b", "", "a%20b"},
+		{"a_b", "", "a%5Fb"},
+	} {
+		if key := HistoryKey(testCase.name, testCase.nodeIP); key != testCase.expected {
+			t.Fatalf("expect %s. Got %s", testCase.expected, key)
+		}
+	}
+}
+
+func TestApplyHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dcos-checks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cfg := &CLIConfigFlags{
+		History:       true,
+		HistoryDir:    dir,
+		EscalateAfter: 3,
+		FlapThreshold: 3,
+		FlapWindow:    5,
+	}
+
+	run := func(status int) Result {
+		result := Result{Name: "check", Status: status, Start: time.Now()}
+		if status != constants.StatusOK {
+			result.Err = errors.New("not ok")
+		}
+		return ApplyHistory(cfg, []Result{result})[0]
+	}
+
+	for i, status := range []int{constants.StatusWarning, constants.StatusFailure} {
+		if result := run(status); result.Escalated || result.Status != status {
+			t.Fatalf("run %d: expect status %d. Got %+v", i, status, result)
+		}
+	}
+
+	result := run(constants.StatusWarning)
+	if !result.Escalated || result.Status != constants.StatusFailure {
+		t.Fatalf("expect a warning to be escalated after 3 non-OK results. Got %+v", result)
+	}
+
+	if result.Flapping {
+		t.Fatal("expect the check not to be flapping")
+	}
+
+	run(constants.StatusOK)
+	if result := run(constants.StatusWarning); !result.Flapping || result.Escalated {
+		t.Fatalf("expect the check to be flapping and not escalated. Got %+v", result)
+	}
+
+	entries, err := History{Dir: dir}.Load("check")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 5 || entries[2].Status != constants.StatusWarning || entries[2].Error != "not ok" {
+		t.Fatalf("expect the original statuses to be recorded. Got %+v", entries)
+	}
+}
@@ -1,6 +1,7 @@
 package common
 
 import (
+	"context"
 	"io/ioutil"
 	"net"
 	"net/http"
@@ -20,14 +21,14 @@ type URLFields struct {
 	Path string
 }
 
-// HTTPRequest verifies the results of the request
-func HTTPRequest(cfg *CLIConfigFlags, urlOptions URLFields) (int, []byte, error) {
-	httpClient, err := client.NewClient(cfg.IAMConfig, cfg.CACert)
+// HTTPRequest verifies the results of the request. The request is canceled when ctx is done.
+func HTTPRequest(ctx context.Context, cfg *CLIConfigFlags, urlOptions URLFields) (int, []byte, error) {
+	httpClient, err := client.NewClientContext(ctx, cfg.IAMConfig, cfg.CACert)
 	if err != nil {
 		return 0, nil, errors.Wrap(err, "unable to create HTTP client")
 	}
 
-	url, err := GetURL(httpClient, cfg, urlOptions)
+	url, err := requestURL(ctx, cfg, httpClient, urlOptions)
 	if err != nil {
 		return 0, nil, err
 	}
@@ -38,7 +39,7 @@ func HTTPRequest(cfg *CLIConfigFlags, urlOptions URLFields) (int, []byte, error)
 		return 0, nil, errors.Wrap(err, "unable to create a new HTTP request")
 	}
 
-	resp, err := httpClient.Do(req)
+	resp, err := httpClient.Do(req.WithContext(ctx))
 	if err != nil {
 		return 0, nil, errors.Wrapf(err, "unable to execute GET %s", url)
 	}
@@ -52,6 +53,18 @@ func HTTPRequest(cfg *CLIConfigFlags, urlOptions URLFields) (int, []byte, error)
 	return resp.StatusCode, responseData, nil
 }
 
+// requestURL returns the URL of a request HTTPRequest makes. An empty host is resolved to the node IP.
+func requestURL(ctx context.Context, cfg *CLIConfigFlags, httpClient *http.Client, urlOptions URLFields) (*url.URL, error) {
+	if urlOptions.Host == "" {
+		ip, err := NodeIP(ctx, cfg, httpClient)
+		if err != nil {
+			return nil, err
+		}
+		urlOptions.Host = ip.String()
+	}
+	return GetURL(httpClient, cfg, urlOptions)
+}
+
 // GetURL returns a URL appropriate for the supplied config flags and url fields
 func GetURL(httpClient *http.Client, cfg *CLIConfigFlags, urlOptions URLFields) (*url.URL, error) {
 	scheme := constants.HTTPScheme
@@ -0,0 +1,138 @@
+package common
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/dcos/dcos-checks/constants"
+	"github.com/pkg/errors"
+)
+
+// junitSuiteName is a name of the test suite and a prefix of the test case class names.
+const junitSuiteName = "dcos-checks"
+
+// junitTestSuite is a JUnit XML report of a single run of the checks.
+type junitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr,omitempty"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+// junitTestCase is a result of a single check.
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+// junitMessage is a failure or skip reason of a test case.
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnitReport writes the results to w as a JUnit XML test suite with a test case per check.
+// Failed and unknown checks are reported as failures. Warnings are reported as skipped test cases,
+// or as failures if cfg.JUnitWarningsAsFailures is set.
+func WriteJUnitReport(w io.Writer, cfg *CLIConfigFlags, results []Result) error {
+	suite := junitTestSuite{Name: junitSuiteName}
+
+	var start, end time.Time
+	for _, result := range results {
+		if !result.Start.IsZero() {
+			if start.IsZero() || result.Start.Before(start) {
+				start = result.Start
+			}
+			if resultEnd := result.Start.Add(result.Duration); resultEnd.After(end) {
+				end = resultEnd
+			}
+		}
+
+		testCase := newJUnitTestCase(cfg, result)
+		if testCase.Failure != nil {
+			suite.Failures++
+		}
+		if testCase.Skipped != nil {
+			suite.Skipped++
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+
+	suite.Tests = len(suite.TestCases)
+	suite.Time = junitSeconds(end.Sub(start))
+	if !start.IsZero() {
+		suite.Timestamp = start.UTC().Format("2006-01-02T15:04:05")
+	}
+
+	body, err := xml.MarshalIndent(suite, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "unable to encode JUnit report")
+	}
+
+	_, err = fmt.Fprintf(w, "%s%s\n", xml.Header, body)
+	return err
+}
+
+// newJUnitTestCase returns a test case for the check result.
+func newJUnitTestCase(cfg *CLIConfigFlags, result Result) junitTestCase {
+	// results of cluster runs are grouped by the node they were executed against.
+	className := junitSuiteName
+	if result.NodeIP != "" {
+		className = fmt.Sprintf("%s.%s", junitSuiteName, result.NodeIP)
+	}
+
+	testCase := junitTestCase{
+		Name:      resultName(result),
+		ClassName: className,
+		Time:      junitSeconds(result.Duration),
+		SystemOut: result.Output,
+	}
+
+	text := resultMessage(result)
+	if lines := remediationLines(result); len(lines) > 0 {
+		text = strings.TrimSpace(text + "\n" + strings.Join(lines, "\n"))
+	}
+
+	message := &junitMessage{
+		Message: firstLine(text),
+		Type:    StatusName(result.Status),
+		Text:    text,
+	}
+
+	switch normalizeStatus(result.Status) {
+	case constants.StatusOK:
+	case constants.StatusUnknown:
+		if result.Skipped {
+			testCase.Skipped = message
+		} else {
+			testCase.Failure = message
+		}
+	case constants.StatusWarning:
+		if cfg.JUnitWarningsAsFailures {
+			testCase.Failure = message
+		} else {
+			testCase.Skipped = message
+		}
+	default:
+		testCase.Failure = message
+	}
+
+	return testCase
+}
+
+// junitSeconds formats a duration as seconds with millisecond precision.
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,68 @@
+package common
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"github.com/dcos/dcos-checks/constants"
+)
+
+func TestWriteJUnitReport(t *testing.T) {
+	results := append(newTestResults(), Result{
+		Name:   "warning",
+		Output: "almost good\nmore details",
+		Status: constants.StatusWarning,
+		NodeIP: "10.0.0.1",
+	})
+
+	for _, tc := range []struct {
+		warningsAsFailures bool
+		failures           int
+		skipped            int
+	}{
+		{warningsAsFailures: false, failures: 1, skipped: 1},
+		{warningsAsFailures: true, failures: 2, skipped: 0},
+	} {
+		cfg := &CLIConfigFlags{JUnitWarningsAsFailures: tc.warningsAsFailures}
+
+		var buf bytes.Buffer
+		if err := WriteJUnitReport(&buf, cfg, results); err != nil {
+			t.Fatal(err)
+		}
+
+		var suite junitTestSuite
+		if err := xml.Unmarshal(buf.Bytes(), &suite); err != nil {
+			t.Fatalf("invalid JUnit report %s: %s", buf.String(), err)
+		}
+
+		if suite.Tests != 3 || suite.Failures != tc.failures || suite.Skipped != tc.skipped {
+			t.Fatalf("expect 3 tests, %d failures and %d skipped. Got %d, %d and %d", tc.failures, tc.skipped,
+				suite.Tests, suite.Failures, suite.Skipped)
+		}
+
+		ok := suite.TestCases[0]
+		if ok.Name != "ok" || ok.Time != "1.500" || ok.SystemOut != "all is good" || ok.Failure != nil || ok.Skipped != nil {
+			t.Fatalf("unexpected test case %+v", ok)
+		}
+
+		failure := suite.TestCases[1]
+		if failure.Failure == nil || failure.Failure.Text != "some error" || failure.Failure.Type != "FAILURE" {
+			t.Fatalf("expect the error as failure text. Got %+v", failure)
+		}
+
+		warning := suite.TestCases[2]
+		if warning.ClassName != "dcos-checks.10.0.0.1" {
+			t.Fatalf("expect class name to contain the node IP. Got %s", warning.ClassName)
+		}
+
+		message := warning.Skipped
+		if tc.warningsAsFailures {
+			message = warning.Failure
+		}
+
+		if message == nil || message.Message != "almost good" {
+			t.Fatalf("unexpected warning test case %+v", warning)
+		}
+	}
+}
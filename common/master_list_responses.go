@@ -1,7 +1,12 @@
-package version
+package common
 
 // masterListResponses response for leader.mesos/master.mesos
 type masterListResponses []struct {
 	Host string `json:"host"`
 	IP   string `json:"ip"`
 }
+
+// masterStateResponse is a part of the Mesos master /state response.
+type masterStateResponse struct {
+	ID string `json:"id"`
+}
@@ -0,0 +1,112 @@
+package common
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/dcos/dcos-checks/constants"
+)
+
+// nagiosStatusName returns a Nagios plugin name of a check status.
+func nagiosStatusName(status int) string {
+	switch normalizeStatus(status) {
+	case constants.StatusOK:
+		return "OK"
+	case constants.StatusWarning:
+		return "WARNING"
+	case constants.StatusFailure:
+		return "CRITICAL"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// nagiosMessage returns the check output followed by the error, if any, so the summary line
+// produced by the check is kept if it returned an error.
+func nagiosMessage(result Result) string {
+	message := strings.TrimSpace(result.Output)
+	if result.Err == nil {
+		return message
+	}
+
+	if message == "" {
+		return result.Err.Error()
+	}
+	return fmt.Sprintf("%s\nError: %s", message, result.Err)
+}
+
+// WriteNagiosOutput writes the results to w in Nagios plugin output format:
+//
+//	STATUS - summary | perfdata
+//	long output
+//
+// The status constants match the Nagios plugin exit codes, so the binary can be used
+// as a Nagios or Icinga plugin. If multiple checks are executed, performance data labels
+// are prefixed with the check name.
+func WriteNagiosOutput(w io.Writer, cfg *CLIConfigFlags, results []Result) error {
+	var summary string
+	var longOutput, perfData []string
+
+	if len(results) == 1 {
+		result := results[0]
+		message := nagiosMessage(result)
+		summary = firstLine(message)
+		if summary == "" {
+			summary = fmt.Sprintf("%s is %s", resultName(result), StatusName(result.Status))
+		}
+
+		if i := strings.Index(message, "\n"); i >= 0 {
+			longOutput = append(longOutput, message[i+1:])
+		}
+		longOutput = append(longOutput, remediationLines(result)...)
+
+		for _, p := range result.PerfData {
+			perfData = append(perfData, p.String())
+		}
+	} else {
+		var failed []string
+		for _, result := range results {
+			name := resultName(result)
+			if normalizeStatus(result.Status) != constants.StatusOK {
+				failed = append(failed, name)
+			}
+
+			line := fmt.Sprintf("[%s] %s", nagiosStatusName(result.Status), name)
+			if message := nagiosMessage(result); message != "" {
+				line += ": " + strings.Replace(message, "\n", "\n  ", -1)
+			}
+			for _, remediation := range remediationLines(result) {
+				line += "\n  " + remediation
+			}
+			longOutput = append(longOutput, line)
+
+			for _, p := range result.PerfData {
+				p.Label = name + "." + p.Label
+				perfData = append(perfData, p.String())
+			}
+		}
+
+		summary = fmt.Sprintf("%d checks OK", len(results))
+		if len(failed) > 0 {
+			summary = fmt.Sprintf("%d of %d checks not OK: %s", len(failed), len(results), strings.Join(failed, ", "))
+		}
+	}
+
+	// the pipe character separates performance data and cannot be a part of the text output.
+	line := fmt.Sprintf("%s - %s", nagiosStatusName(WorstStatus(results)), strings.Replace(summary, "|", "/", -1))
+	if len(perfData) > 0 {
+		line += " | " + strings.Join(perfData, " ")
+	}
+
+	if _, err := fmt.Fprintln(w, line); err != nil {
+		return err
+	}
+
+	for _, output := range longOutput {
+		if _, err := fmt.Fprintln(w, strings.Replace(output, "|", "/", -1)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package common
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/dcos/dcos-checks/constants"
+)
+
+func TestPerfDataString(t *testing.T) {
+	for _, tc := range []struct {
+		perfData PerfData
+		expected string
+	}{
+		{PerfData{Label: "versions", Value: 1}, "versions=1"},
+		{PerfData{Label: "esterror", Value: 0.0015, UOM: "s", Warning: "0.1", Critical: "1"}, "esterror=0.0015s;0.1;1"},
+		{PerfData{Label: "unhealthy units", Value: 2, Critical: "0", Min: "0", Max: "10"}, "'unhealthy units'=2;;0;0;10"},
+	} {
+		if s := tc.perfData.String(); s != tc.expected {
+			t.Fatalf("expect %s. Got %s", tc.expected, s)
+		}
+	}
+}
+
+func TestWriteNagiosOutputSingle(t *testing.T) {
+	results := []Result{{
+		Name:     "components",
+		Output:   "component a has health status 1\ncomponent b has health status 1",
+		Status:   constants.StatusFailure,
+		PerfData: []PerfData{{Label: "unhealthy_units", Value: 2, Critical: "0"}},
+	}}
+
+	var buf bytes.Buffer
+	if err := WriteNagiosOutput(&buf, &CLIConfigFlags{}, results); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "CRITICAL - component a has health status 1 | unhealthy_units=2;;0\ncomponent b has health status 1\n"
+	if buf.String() != expected {
+		t.Fatalf("expect:\n%s\nGot:\n%s", expected, buf.String())
+	}
+}
+
+func TestWriteNagiosOutputSingleError(t *testing.T) {
+	results := []Result{{
+		Name:   "version",
+		Output: "1 of 6 nodes are unreachable: 10.0.1.2\nunreachable:\n  10.0.1.2 (agent)",
+		Status: constants.StatusWarning,
+		Err:    errors.New("connection refused"),
+	}}
+
+	var buf bytes.Buffer
+	if err := WriteNagiosOutput(&buf, &CLIConfigFlags{}, results); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "WARNING - 1 of 6 nodes are unreachable: 10.0.1.2\nunreachable:\n  10.0.1.2 (agent)\nError: connection refused\n"
+	if buf.String() != expected {
+		t.Fatalf("expect:\n%s\nGot:\n%s", expected, buf.String())
+	}
+
+	results[0].Output = ""
+	buf.Reset()
+	if err := WriteNagiosOutput(&buf, &CLIConfigFlags{}, results); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "WARNING - connection refused\n"; buf.String() != expected {
+		t.Fatalf("expect:\n%s\nGot:\n%s", expected, buf.String())
+	}
+}
+
+func TestWriteNagiosOutputMultiple(t *testing.T) {
+	results := newTestResults()
+	results[0].PerfData = []PerfData{{Label: "versions", Value: 1}}
+
+	var buf bytes.Buffer
+	if err := WriteNagiosOutput(&buf, &CLIConfigFlags{}, results); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `CRITICAL - 1 of 2 checks not OK: failure | ok.versions=1
+[OK] ok: all is good
+[CRITICAL] failure: some error
+`
+	if buf.String() != expected {
+		t.Fatalf("expect:\n%s\nGot:\n%s", expected, buf.String())
+	}
+}
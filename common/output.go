@@ -0,0 +1,177 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// Supported output formats.
+const (
+	// OutputText is a human readable free-form text output.
+	OutputText = "text"
+
+	// OutputJSON emits a JSON document per check.
+	OutputJSON = "json"
+
+	// OutputYAML emits a YAML document per check.
+	OutputYAML = "yaml"
+
+	// OutputPrometheus emits metrics in Prometheus text exposition format.
+	OutputPrometheus = "prometheus"
+
+	// OutputJUnit emits a JUnit XML report with a test case per check.
+	OutputJUnit = "junit"
+
+	// OutputNagios emits Nagios plugin output with a status line, performance data and long output.
+	OutputNagios = "nagios"
+)
+
+// resultWriter writes check results to w in a machine readable format.
+type resultWriter func(w io.Writer, cfg *CLIConfigFlags, results []Result) error
+
+// resultWriters maps machine readable output formats to result writers.
+var resultWriters = map[string]resultWriter{
+	OutputJSON:       writeJSONDocuments,
+	OutputYAML:       writeYAMLDocuments,
+	OutputPrometheus: WritePrometheusMetrics,
+	OutputJUnit:      WriteJUnitReport,
+	OutputNagios:     WriteNagiosOutput,
+}
+
+// ResultDocument is a machine readable representation of a check result.
+type ResultDocument struct {
+	Name       string  `json:"name,omitempty" yaml:"name,omitempty"`
+	ID         string  `json:"id" yaml:"id"`
+	Status     int     `json:"status" yaml:"status"`
+	StatusName string  `json:"status_name" yaml:"status_name"`
+	Output     string  `json:"output" yaml:"output"`
+	Error      string  `json:"error,omitempty" yaml:"error,omitempty"`
+	StartTime  string  `json:"start_time" yaml:"start_time"`
+	Duration   float64 `json:"duration_seconds" yaml:"duration_seconds"`
+	Attempts   int     `json:"attempts" yaml:"attempts"`
+	NodeIP     string  `json:"node_ip,omitempty" yaml:"node_ip,omitempty"`
+	Role       string  `json:"role,omitempty" yaml:"role,omitempty"`
+	Skipped    bool    `json:"skipped,omitempty" yaml:"skipped,omitempty"`
+	Escalated  bool    `json:"escalated,omitempty" yaml:"escalated,omitempty"`
+	Flapping   bool    `json:"flapping,omitempty" yaml:"flapping,omitempty"`
+
+	Data        map[string]interface{} `json:"data,omitempty" yaml:"data,omitempty"`
+	Items       []ItemDocument         `json:"items,omitempty" yaml:"items,omitempty"`
+	Remediation []Remediation          `json:"remediation,omitempty" yaml:"remediation,omitempty"`
+}
+
+// ItemDocument is a machine readable representation of a result of an item checked by a check.
+type ItemDocument struct {
+	Name       string `json:"name" yaml:"name"`
+	Status     int    `json:"status" yaml:"status"`
+	StatusName string `json:"status_name" yaml:"status_name"`
+	Output     string `json:"output,omitempty" yaml:"output,omitempty"`
+}
+
+// ValidateOutputFormat returns an error if the given output format is not supported.
+func ValidateOutputFormat(format string) error {
+	if format == OutputText {
+		return nil
+	}
+
+	if _, ok := resultWriters[format]; !ok {
+		return errors.Errorf("invalid output format %s", format)
+	}
+	return nil
+}
+
+// WriteResults writes the results to w in the output format set in cfg.
+func WriteResults(w io.Writer, cfg *CLIConfigFlags, results []Result) error {
+	if cfg.Output == OutputText || cfg.Output == "" {
+		PrintSummary(w, results)
+		return nil
+	}
+
+	write, ok := resultWriters[cfg.Output]
+	if !ok {
+		return errors.Errorf("invalid output format %s", cfg.Output)
+	}
+
+	return write(w, cfg, results)
+}
+
+// NewResultDocuments returns a machine readable document for each result.
+func NewResultDocuments(cfg *CLIConfigFlags, results []Result) []ResultDocument {
+	documents := make([]ResultDocument, 0, len(results))
+	for _, result := range results {
+		// the local node IP is only detected by the run if it is not set explicitly with --node-ip.
+		resultNodeIP, role := cfg.NodeIPStr, cfg.Role
+		switch {
+		case result.NodeIP != "":
+			resultNodeIP, role = result.NodeIP, result.Role
+		case resultNodeIP == "":
+			resultNodeIP = result.LocalIP()
+		}
+
+		doc := ResultDocument{
+			Name:       result.Name,
+			ID:         result.ID,
+			Status:     result.Status,
+			StatusName: StatusName(result.Status),
+			Output:     result.Output,
+			StartTime:  result.Start.Format(time.RFC3339Nano),
+			Duration:   result.Duration.Seconds(),
+			Attempts:   result.Attempts,
+			NodeIP:     resultNodeIP,
+			Role:       role,
+			Skipped:    result.Skipped,
+			Escalated:  result.Escalated,
+			Flapping:   result.Flapping,
+
+			Data:        result.Data,
+			Remediation: result.Remediation,
+		}
+
+		for _, item := range result.Items {
+			doc.Items = append(doc.Items, ItemDocument{
+				Name:       item.Name,
+				Status:     item.Status,
+				StatusName: StatusName(item.Status),
+				Output:     item.Output,
+			})
+		}
+
+		if result.Err != nil {
+			doc.Error = result.Err.Error()
+		}
+		documents = append(documents, doc)
+	}
+
+	return documents
+}
+
+// writeJSONDocuments writes a JSON document per line.
+func writeJSONDocuments(w io.Writer, cfg *CLIConfigFlags, results []Result) error {
+	encoder := json.NewEncoder(w)
+	for _, doc := range NewResultDocuments(cfg, results) {
+		if err := encoder.Encode(doc); err != nil {
+			return errors.Wrap(err, "unable to encode JSON document")
+		}
+	}
+	return nil
+}
+
+// writeYAMLDocuments writes YAML documents separated by the document start marker.
+func writeYAMLDocuments(w io.Writer, cfg *CLIConfigFlags, results []Result) error {
+	for _, doc := range NewResultDocuments(cfg, results) {
+		body, err := yaml.Marshal(doc)
+		if err != nil {
+			return errors.Wrap(err, "unable to encode YAML document")
+		}
+
+		if _, err := fmt.Fprintf(w, "---\n%s", body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
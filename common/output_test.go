@@ -0,0 +1,112 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dcos/dcos-checks/constants"
+	"gopkg.in/yaml.v2"
+)
+
+func newTestResults() []Result {
+	start := time.Date(2017, 6, 1, 10, 0, 0, 0, time.UTC)
+	return []Result{
+		{
+			Name:     "ok",
+			ID:       "fakeCheck",
+			Output:   "all is good",
+			Status:   constants.StatusOK,
+			Start:    start,
+			Duration: 1500 * time.Millisecond,
+		},
+		{
+			Name:   "failure",
+			ID:     "fakeCheck",
+			Status: constants.StatusFailure,
+			Err:    errors.New("some error"),
+			Start:  start,
+		},
+	}
+}
+
+func TestWriteResultsJSON(t *testing.T) {
+	cfg := &CLIConfigFlags{
+		NodeIPStr: "127.0.0.1",
+		Role:      "master",
+		Output:    OutputJSON,
+	}
+
+	var buf bytes.Buffer
+	if err := WriteResults(&buf, cfg, newTestResults()); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expect a JSON document per check. Got %s", buf.String())
+	}
+
+	var doc ResultDocument
+	if err := json.Unmarshal([]byte(lines[0]), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.ID != "fakeCheck" || doc.StatusName != "OK" || doc.Output != "all is good" || doc.Duration != 1.5 {
+		t.Fatalf("unexpected document %+v", doc)
+	}
+
+	if doc.NodeIP != "127.0.0.1" || doc.Role != "master" {
+		t.Fatalf("expect node IP 127.0.0.1 and role master. Got %s and %s", doc.NodeIP, doc.Role)
+	}
+
+	if err := json.Unmarshal([]byte(lines[1]), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.Status != constants.StatusFailure || doc.StatusName != "FAILURE" || doc.Error != "some error" {
+		t.Fatalf("unexpected document %+v", doc)
+	}
+}
+
+func TestWriteResultsYAML(t *testing.T) {
+	cfg := &CLIConfigFlags{
+		NodeIPStr: "127.0.0.1",
+		Role:      "agent",
+		Output:    OutputYAML,
+	}
+
+	var buf bytes.Buffer
+	if err := WriteResults(&buf, cfg, newTestResults()); err != nil {
+		t.Fatal(err)
+	}
+
+	documents := strings.Split(buf.String(), "---\n")
+	if len(documents) != 3 {
+		t.Fatalf("expect 2 YAML documents. Got %s", buf.String())
+	}
+
+	var doc ResultDocument
+	if err := yaml.Unmarshal([]byte(documents[2]), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.Name != "failure" || doc.Error != "some error" || doc.Role != "agent" {
+		t.Fatalf("unexpected document %+v", doc)
+	}
+}
+
+func TestValidateOutputFormat(t *testing.T) {
+	for _, format := range []string{OutputText, OutputJSON, OutputYAML, OutputPrometheus, OutputJUnit, OutputNagios} {
+		if err := ValidateOutputFormat(format); err != nil {
+			t.Fatalf("expect format %s to be valid. Got %s", format, err)
+		}
+	}
+
+	if err := ValidateOutputFormat("xml"); err == nil {
+		t.Fatal("expect error for an invalid output format")
+	}
+}
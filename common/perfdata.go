@@ -0,0 +1,46 @@
+package common
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PerfData is a measurable value computed by a check, reported in Nagios plugin
+// performance data format 'label'=value[UOM];[warn];[crit];[min];[max].
+type PerfData struct {
+	Label string
+	Value float64
+
+	// UOM is a unit of measurement, e.g. s, %, B or c.
+	UOM string
+
+	// Warning and Critical are threshold ranges, e.g. 10 or 5:10.
+	Warning  string
+	Critical string
+
+	Min string
+	Max string
+}
+
+// String returns the performance data in Nagios plugin format.
+func (p PerfData) String() string {
+	fields := []string{
+		fmt.Sprintf("%s=%s%s", perfDataLabel(p.Label), strconv.FormatFloat(p.Value, 'f', -1, 64), p.UOM),
+		p.Warning, p.Critical, p.Min, p.Max,
+	}
+
+	// trailing unfilled fields can be dropped.
+	for len(fields) > 1 && fields[len(fields)-1] == "" {
+		fields = fields[:len(fields)-1]
+	}
+	return strings.Join(fields, ";")
+}
+
+// perfDataLabel quotes a label if it contains spaces, equal signs or quotes.
+func perfDataLabel(label string) string {
+	if !strings.ContainsAny(label, " ='") {
+		return label
+	}
+	return "'" + strings.Replace(label, "'", "''", -1) + "'"
+}
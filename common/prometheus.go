@@ -0,0 +1,220 @@
+package common
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// prometheusMetric describes a gauge exported for every check result.
+type prometheusMetric struct {
+	name  string
+	help  string
+	value func(Result) float64
+}
+
+var prometheusMetrics = []prometheusMetric{
+	{
+		name:  "dcos_check_status",
+		help:  "Status of a DC/OS check: 0 OK, 1 warning, 2 failure, 3 unknown.",
+		value: func(r Result) float64 { return float64(normalizeStatus(r.Status)) },
+	},
+	{
+		name:  "dcos_check_duration_seconds",
+		help:  "Time it took to run a DC/OS check, including retries.",
+		value: func(r Result) float64 { return r.Duration.Seconds() },
+	},
+	{
+		name:  "dcos_check_last_run_timestamp",
+		help:  "Unix time a DC/OS check was last started.",
+		value: func(r Result) float64 { return float64(r.Start.UnixNano()) / 1e9 },
+	},
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// WritePrometheusMetrics writes the results to w in Prometheus text exposition format.
+func WritePrometheusMetrics(w io.Writer, cfg *CLIConfigFlags, results []Result) error {
+	for _, metric := range prometheusMetrics {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", metric.name, metric.help, metric.name); err != nil {
+			return err
+		}
+
+		for _, result := range results {
+			check := resultName(result)
+
+			// results of cluster runs are labeled with the node they were executed against.
+			labels := fmt.Sprintf("check=\"%s\",role=\"%s\"", labelValueReplacer.Replace(check),
+				labelValueReplacer.Replace(cfg.Role))
+			if result.NodeIP != "" {
+				labels = fmt.Sprintf("check=\"%s\",role=\"%s\",node=\"%s\"", labelValueReplacer.Replace(check),
+					labelValueReplacer.Replace(result.Role), labelValueReplacer.Replace(result.NodeIP))
+			}
+
+			if _, err := fmt.Fprintf(w, "%s{%s} %g\n", metric.name, labels, metric.value(result)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WritePrometheusTextfile atomically replaces the file at path with the metrics of the results,
+// so node_exporter textfile collector never reads a partially written file.
+func WritePrometheusTextfile(path string, cfg *CLIConfigFlags, results []Result) error {
+	var buf bytes.Buffer
+	if err := WritePrometheusMetrics(&buf, cfg, results); err != nil {
+		return err
+	}
+	return replaceFile(path, buf.Bytes())
+}
+
+// MergePrometheusTextfile atomically updates the series of the results in the file at path,
+// keeping the series of other checks, e.g. written by a run of other checks. Concurrent
+// updates of the file are serialized by a lock file next to it.
+func MergePrometheusTextfile(path string, cfg *CLIConfigFlags, results []Result) error {
+	unlock, err := lockFile(path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	existing, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "unable to read metrics from %s", path)
+	}
+
+	var buf bytes.Buffer
+	if err := WritePrometheusMetrics(&buf, cfg, results); err != nil {
+		return err
+	}
+	return replaceFile(path, mergeMetrics(existing, buf.Bytes()))
+}
+
+// metricFamily is a metric read from Prometheus text exposition format.
+type metricFamily struct {
+	comments []string
+
+	// series are the metric samples in order, indexed by the series name and labels.
+	series  []string
+	samples map[string]string
+}
+
+// parseMetrics returns the metric families of a text written by WritePrometheusMetrics and their names in order.
+func parseMetrics(body []byte) ([]string, map[string]*metricFamily) {
+	var names []string
+	families := make(map[string]*metricFamily)
+	family := func(name string) *metricFamily {
+		if _, ok := families[name]; !ok {
+			names = append(names, name)
+			families[name] = &metricFamily{samples: make(map[string]string)}
+		}
+		return families[name]
+	}
+
+	for _, line := range strings.Split(string(body), "\n") {
+		switch {
+		case strings.HasPrefix(line, "# HELP ") || strings.HasPrefix(line, "# TYPE "):
+			if fields := strings.Fields(line); len(fields) > 2 {
+				f := family(fields[2])
+				f.comments = append(f.comments, line)
+			}
+		case line == "" || strings.HasPrefix(line, "#"):
+		default:
+			// a sample is name{labels} value, the value does not contain spaces.
+			i := strings.LastIndex(line, " ")
+			j := strings.IndexAny(line, "{ ")
+			if i < 0 || j < 0 {
+				continue
+			}
+
+			f := family(line[:j])
+			series := line[:i]
+			if _, ok := f.samples[series]; !ok {
+				f.series = append(f.series, series)
+			}
+			f.samples[series] = line
+		}
+	}
+	return names, families
+}
+
+// mergeMetrics returns the existing metrics with the samples of the same series replaced by the
+// updated samples. Series not present in the existing metrics are appended to their metric.
+func mergeMetrics(existing, updated []byte) []byte {
+	names, families := parseMetrics(existing)
+	updatedNames, updatedFamilies := parseMetrics(updated)
+	for _, name := range updatedNames {
+		update := updatedFamilies[name]
+		family, ok := families[name]
+		if !ok {
+			names = append(names, name)
+			families[name] = update
+			continue
+		}
+
+		family.comments = update.comments
+		for _, series := range update.series {
+			if _, ok := family.samples[series]; !ok {
+				family.series = append(family.series, series)
+			}
+			family.samples[series] = update.samples[series]
+		}
+	}
+
+	var buf bytes.Buffer
+	for _, name := range names {
+		family := families[name]
+		for _, line := range family.comments {
+			fmt.Fprintln(&buf, line)
+		}
+		for _, series := range family.series {
+			fmt.Fprintln(&buf, family.samples[series])
+		}
+	}
+	return buf.Bytes()
+}
+
+// replaceFile atomically replaces the file at path with body, so node_exporter textfile
+// collector never reads a partially written file.
+func replaceFile(path string, body []byte) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path))
+	if err != nil {
+		return errors.Wrap(err, "unable to create a temporary file")
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(body); err != nil {
+		f.Close()
+		return errors.Wrapf(err, "unable to write metrics to %s", f.Name())
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(f.Name(), 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+// writeTextfile updates the series of the results in the Prometheus textfile set in cfg, if any.
+// The textfile is merged, so a run of some checks does not drop the metrics of the other checks.
+func writeTextfile(cfg *CLIConfigFlags, results []Result) {
+	if cfg.PrometheusTextfile == "" {
+		return
+	}
+
+	if err := MergePrometheusTextfile(cfg.PrometheusTextfile, cfg, results); err != nil {
+		logrus.Errorf("Unable to write Prometheus textfile: %s", err)
+	}
+}
@@ -0,0 +1,126 @@
+package common
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dcos/dcos-checks/constants"
+)
+
+const expectedMetrics = `# HELP dcos_check_status Status of a DC/OS check: 0 OK, 1 warning, 2 failure, 3 unknown.
+# TYPE dcos_check_status gauge
+dcos_check_status{check="ok",role="master"} 0
+dcos_check_status{check="failure",role="master"} 2
+# HELP dcos_check_duration_seconds Time it took to run a DC/OS check, including retries.
+# TYPE dcos_check_duration_seconds gauge
+dcos_check_duration_seconds{check="ok",role="master"} 1.5
+dcos_check_duration_seconds{check="failure",role="master"} 0
+# HELP dcos_check_last_run_timestamp Unix time a DC/OS check was last started.
+# TYPE dcos_check_last_run_timestamp gauge
+dcos_check_last_run_timestamp{check="ok",role="master"} 1.4963112e+09
+dcos_check_last_run_timestamp{check="failure",role="master"} 1.4963112e+09
+`
+
+func TestWritePrometheusMetrics(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WritePrometheusMetrics(&buf, &CLIConfigFlags{Role: "master"}, newTestResults()); err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.String() != expectedMetrics {
+		t.Fatalf("expect metrics:\n%s\nGot:\n%s", expectedMetrics, buf.String())
+	}
+}
+
+func TestWritePrometheusTextfile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dcos-checks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "dcos_checks.prom")
+	if err := WritePrometheusTextfile(path, &CLIConfigFlags{Role: "master"}, newTestResults()); err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(body) != expectedMetrics {
+		t.Fatalf("expect metrics:\n%s\nGot:\n%s", expectedMetrics, body)
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 1 {
+		t.Fatalf("expect temporary files to be removed. Got %d files", len(files))
+	}
+}
+
+func TestMergePrometheusTextfile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dcos-checks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cfg := &CLIConfigFlags{Role: "master"}
+	path := filepath.Join(dir, "dcos_checks.prom")
+	if err := MergePrometheusTextfile(path, cfg, newTestResults()); err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(body) != expectedMetrics {
+		t.Fatalf("expect metrics:\n%s\nGot:\n%s", expectedMetrics, body)
+	}
+
+	// a run of a single check updates its series and keeps the series of the other checks.
+	start := time.Date(2017, 6, 1, 11, 0, 0, 0, time.UTC)
+	results := []Result{
+		{Name: "failure", Status: constants.StatusWarning, Start: start, Duration: time.Second},
+		{Name: "new", Status: constants.StatusOK, Start: start},
+	}
+	if err := MergePrometheusTextfile(path, cfg, results); err != nil {
+		t.Fatal(err)
+	}
+
+	body, err = ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `# HELP dcos_check_status Status of a DC/OS check: 0 OK, 1 warning, 2 failure, 3 unknown.
+# TYPE dcos_check_status gauge
+dcos_check_status{check="ok",role="master"} 0
+dcos_check_status{check="failure",role="master"} 1
+dcos_check_status{check="new",role="master"} 0
+# HELP dcos_check_duration_seconds Time it took to run a DC/OS check, including retries.
+# TYPE dcos_check_duration_seconds gauge
+dcos_check_duration_seconds{check="ok",role="master"} 1.5
+dcos_check_duration_seconds{check="failure",role="master"} 1
+dcos_check_duration_seconds{check="new",role="master"} 0
+# HELP dcos_check_last_run_timestamp Unix time a DC/OS check was last started.
+# TYPE dcos_check_last_run_timestamp gauge
+dcos_check_last_run_timestamp{check="ok",role="master"} 1.4963112e+09
+dcos_check_last_run_timestamp{check="failure",role="master"} 1.4963148e+09
+dcos_check_last_run_timestamp{check="new",role="master"} 1.4963148e+09
+`
+	if string(body) != expected {
+		t.Fatalf("expect metrics:\n%s\nGot:\n%s", expected, body)
+	}
+}
@@ -0,0 +1,183 @@
+package common
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]CheckSpec)
+)
+
+// CheckFactory returns a new instance of a check configured with the given flags and arguments.
+type CheckFactory func(flags *pflag.FlagSet, args []string) (DCOSChecker, error)
+
+// CheckSpec describes a check registered in the check registry.
+type CheckSpec struct {
+	// Name is a unique check name, used as a subcommand name.
+	Name string
+
+	// Description is a short one line description of the check.
+	Description string
+
+	// Long is an optional detailed description of the check.
+	Long string
+
+	// Roles is a list of DC/OS roles the check is applicable to. A check without roles
+	// is only executed when it is selected explicitly.
+	Roles []string
+
+	// Tags is a list of arbitrary labels used to group checks.
+	Tags []string
+
+	// Timeout is a default check timeout. Zero means no timeout.
+	Timeout time.Duration
+
+	// ClusterAccess is set if the check needs to reach other nodes of the cluster.
+	ClusterAccess bool
+
+	// Requires is a list of checks which must not fail for this check to be executed.
+	// Prerequisites are only taken into account if they are executed in the same run.
+	Requires []string
+
+	// Flags adds check specific parameters to the flag set. Optional.
+	Flags func(*pflag.FlagSet)
+
+	// New returns a new instance of the check.
+	New CheckFactory
+}
+
+// Tagger is an optional interface of a check which adds tags depending on its parameters,
+// e.g. components checking the whole cluster with --cluster-wide is tagged cluster.
+type Tagger interface {
+	Tags() []string
+}
+
+// HasRole returns true if the check is applicable to the given role.
+func (s CheckSpec) HasRole(role string) bool {
+	for _, r := range s.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// FlagSet returns a new flag set with the check parameters set to their default values.
+func (s CheckSpec) FlagSet() *pflag.FlagSet {
+	flags := pflag.NewFlagSet(s.Name, pflag.ContinueOnError)
+	if s.Flags != nil {
+		s.Flags(flags)
+	}
+	return flags
+}
+
+// NewTask returns a task for a new check instance. Parameters not set in params
+// have their default values.
+func (s CheckSpec) NewTask(args []string, params map[string]interface{}) (Task, error) {
+	flags := s.FlagSet()
+	if err := s.SetParams(flags, params); err != nil {
+		return Task{}, err
+	}
+	return s.TaskFromFlags(flags, args)
+}
+
+// SetParams sets the check parameters in flags to the values in params. Parameters already
+// set in flags, e.g. on the command line, are kept.
+func (s CheckSpec) SetParams(flags *pflag.FlagSet, params map[string]interface{}) error {
+	for name, value := range params {
+		flag := flags.Lookup(name)
+		if flag == nil {
+			return errors.Errorf("check %s has no parameter %s", s.Name, name)
+		}
+
+		if flag.Changed {
+			continue
+		}
+
+		if err := flags.Set(name, paramValue(value)); err != nil {
+			return errors.Wrapf(err, "invalid value of parameter %s", name)
+		}
+	}
+	return nil
+}
+
+// TaskFromFlags returns a task for a new check instance configured with the given flags, e.g.
+// the flags of a check subcommand. The tags of checks implementing Tagger are added to the spec tags.
+func (s CheckSpec) TaskFromFlags(flags *pflag.FlagSet, args []string) (Task, error) {
+	check, err := s.New(flags, args)
+	if err != nil {
+		return Task{}, errors.Wrapf(err, "unable to initialize check %s", s.Name)
+	}
+
+	tags := s.Tags
+	if tagger, ok := check.(Tagger); ok {
+		tags = append(append([]string(nil), s.Tags...), tagger.Tags()...)
+	}
+
+	return Task{
+		Name:     s.Name,
+		Check:    check,
+		Tags:     tags,
+		Requires: s.Requires,
+		Timeout:  s.Timeout,
+	}, nil
+}
+
+// RegisterCheck adds a check to the registry. It panics if the spec is invalid or
+// a check with the same name is already registered.
+func RegisterCheck(spec CheckSpec) {
+	if spec.Name == "" || spec.New == nil {
+		panic("check name and factory must be set")
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, ok := registry[spec.Name]; ok {
+		panic(fmt.Sprintf("check %s is already registered", spec.Name))
+	}
+	registry[spec.Name] = spec
+}
+
+// LookupCheck returns a registered check by name.
+func LookupCheck(name string) (CheckSpec, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	spec, ok := registry[name]
+	return spec, ok
+}
+
+// Checks returns all registered checks sorted by name.
+func Checks() []CheckSpec {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	specs := make([]CheckSpec, 0, len(registry))
+	for _, spec := range registry {
+		specs = append(specs, spec)
+	}
+
+	sort.Slice(specs, func(i, j int) bool {
+		return specs[i].Name < specs[j].Name
+	})
+	return specs
+}
+
+// ChecksForRole returns all registered checks applicable to the given role, sorted by name.
+func ChecksForRole(role string) []CheckSpec {
+	var specs []CheckSpec
+	for _, spec := range Checks() {
+		if spec.HasRole(role) {
+			specs = append(specs, spec)
+		}
+	}
+	return specs
+}
@@ -0,0 +1,139 @@
+package common
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dcos/dcos-checks/constants"
+	"github.com/spf13/pflag"
+)
+
+func TestRegisterCheck(t *testing.T) {
+	RegisterCheck(CheckSpec{
+		Name:        "test-registry-check",
+		Description: "test check",
+		Roles:       []string{"master"},
+		Timeout:     time.Second,
+		Flags: func(flags *pflag.FlagSet) {
+			flags.String("message", "default message", "check output")
+		},
+		New: func(flags *pflag.FlagSet, args []string) (DCOSChecker, error) {
+			message, err := flags.GetString("message")
+			if err != nil {
+				return nil, err
+			}
+			return newFakeCheck(message, constants.StatusOK, nil), nil
+		},
+	})
+
+	spec, ok := LookupCheck("test-registry-check")
+	if !ok {
+		t.Fatal("expect check to be registered")
+	}
+
+	if !spec.HasRole("master") || spec.HasRole("agent") {
+		t.Fatalf("unexpected roles %v", spec.Roles)
+	}
+
+	var found bool
+	for _, s := range ChecksForRole("master") {
+		found = found || s.Name == spec.Name
+	}
+	if !found {
+		t.Fatal("expect check to be applicable to master role")
+	}
+
+	task, err := spec.NewTask(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if task.Name != spec.Name || task.Timeout != time.Second {
+		t.Fatalf("unexpected task %+v", task)
+	}
+
+	output, _, _ := task.Check.Run(context.TODO(), nil)
+	if output != "default message" {
+		t.Fatalf("expect default parameter value. Got %s", output)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expect panic on duplicate registration")
+		}
+	}()
+	RegisterCheck(spec)
+}
+
+func TestSetParams(t *testing.T) {
+	spec := CheckSpec{
+		Name: "test-params-check",
+		Flags: func(flags *pflag.FlagSet) {
+			flags.String("message", "default message", "check output")
+			flags.StringSlice("items", nil, "checked items")
+		},
+	}
+
+	flags := spec.FlagSet()
+	if err := flags.Parse([]string{"--message", "command line"}); err != nil {
+		t.Fatal(err)
+	}
+
+	params := map[string]interface{}{"message": "config", "items": []interface{}{"a", "b"}}
+	if err := spec.SetParams(flags, params); err != nil {
+		t.Fatal(err)
+	}
+
+	message, _ := flags.GetString("message")
+	items, _ := flags.GetStringSlice("items")
+	if message != "command line" || len(items) != 2 {
+		t.Fatalf("expect the command line to take precedence over params. Got %s, %v", message, items)
+	}
+
+	if err := spec.SetParams(flags, map[string]interface{}{"unknown": "value"}); err == nil {
+		t.Fatal("expect an error for an unknown parameter")
+	}
+}
+
+type fakeTaggedCheck struct {
+	fakeCheck
+}
+
+func (f fakeTaggedCheck) Tags() []string {
+	return []string{"cluster"}
+}
+
+func TestNewTaskTagger(t *testing.T) {
+	spec := CheckSpec{
+		Name: "test-tagged-check",
+		Tags: []string{"http"},
+		New: func(flags *pflag.FlagSet, args []string) (DCOSChecker, error) {
+			return fakeTaggedCheck{*newFakeCheck("", constants.StatusOK, nil)}, nil
+		},
+	}
+
+	task, err := spec.NewTask(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !task.HasTag("http") || !task.HasTag("cluster") || len(spec.Tags) != 1 {
+		t.Fatalf("expect the check tags to be added to the spec tags. Got %v, %v", task.Tags, spec.Tags)
+	}
+}
+
+func TestChecksSorted(t *testing.T) {
+	newCheck := func(flags *pflag.FlagSet, args []string) (DCOSChecker, error) {
+		return newFakeCheck("", constants.StatusOK, nil), nil
+	}
+	RegisterCheck(CheckSpec{Name: "test-sorted-b", New: newCheck})
+	RegisterCheck(CheckSpec{Name: "test-sorted-a", New: newCheck})
+
+	specs := Checks()
+	for i := 1; i < len(specs); i++ {
+		if specs[i-1].Name > specs[i].Name {
+			t.Fatalf("expect checks sorted by name. Got %s before %s", specs[i-1].Name, specs[i].Name)
+		}
+	}
+}
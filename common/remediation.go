@@ -0,0 +1,32 @@
+package common
+
+import (
+	"fmt"
+)
+
+// Remediation describes how to fix a problem found by a check.
+type Remediation struct {
+	// Hint is a human readable suggestion.
+	Hint string `json:"hint" yaml:"hint"`
+
+	// Link is an optional link to the relevant documentation.
+	Link string `json:"link,omitempty" yaml:"link,omitempty"`
+
+	// Command is an optional command which fixes or helps to diagnose the problem.
+	Command string `json:"command,omitempty" yaml:"command,omitempty"`
+}
+
+// remediationLines returns text lines describing the remediation of the result.
+func remediationLines(result Result) []string {
+	var lines []string
+	for _, r := range result.Remediation {
+		lines = append(lines, fmt.Sprintf("Remediation: %s", r.Hint))
+		if r.Command != "" {
+			lines = append(lines, fmt.Sprintf("  Run: %s", r.Command))
+		}
+		if r.Link != "" {
+			lines = append(lines, fmt.Sprintf("  See: %s", r.Link))
+		}
+	}
+	return lines
+}
@@ -0,0 +1,76 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/dcos/dcos-checks/constants"
+)
+
+// newFakeRemediationCheck returns a check with the given status which always suggests a remediation.
+func newFakeRemediationCheck(status int) fakeDetailedCheck {
+	return fakeDetailedCheck{result: CheckResult{
+		Status:      status,
+		Remediation: []Remediation{{Hint: "restart it", Command: "systemctl restart foo", Link: "https://example.com"}},
+	}}
+}
+
+func TestRunChecksRemediation(t *testing.T) {
+	tasks := []Task{
+		{Name: "ok", Check: newFakeRemediationCheck(constants.StatusOK)},
+		{Name: "failure", Check: newFakeRemediationCheck(constants.StatusFailure)},
+	}
+
+	results := RunChecks(context.TODO(), nil, tasks)
+	if len(results[0].Remediation) != 0 {
+		t.Fatalf("expect no remediation of an OK result. Got %v", results[0].Remediation)
+	}
+
+	if len(results[1].Remediation) != 1 || results[1].Remediation[0].Hint != "restart it" {
+		t.Fatalf("expect remediation of a failed result. Got %v", results[1].Remediation)
+	}
+}
+
+func TestPrintSummaryRemediation(t *testing.T) {
+	results := []Result{{
+		Name:        "failure",
+		ID:          "fakeCheck",
+		Output:      "foo is down",
+		Status:      constants.StatusFailure,
+		Remediation: []Remediation{{Hint: "restart it", Command: "systemctl restart foo", Link: "https://example.com"}},
+	}}
+
+	var buf bytes.Buffer
+	PrintSummary(&buf, results)
+
+	expected := `[FAILURE] failure: fakeCheck
+  foo is down
+  Remediation: restart it
+    Run: systemctl restart foo
+    See: https://example.com
+Overall status: FAILURE
+`
+	if buf.String() != expected {
+		t.Fatalf("expect summary:\n%s\nGot:\n%s", expected, buf.String())
+	}
+}
+
+func TestWriteNagiosOutputRemediation(t *testing.T) {
+	results := []Result{{
+		Name:        "failure",
+		Output:      "foo is down",
+		Status:      constants.StatusFailure,
+		Remediation: []Remediation{{Hint: "restart it", Command: "systemctl restart foo"}},
+	}}
+
+	var buf bytes.Buffer
+	if err := WriteNagiosOutput(&buf, &CLIConfigFlags{}, results); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "CRITICAL - foo is down\nRemediation: restart it\n  Run: systemctl restart foo\n"
+	if buf.String() != expected {
+		t.Fatalf("expect:\n%s\nGot:\n%s", expected, buf.String())
+	}
+}
@@ -0,0 +1,68 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/dcos/dcos-go/dcos"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultRolesDir is a directory with DC/OS role marker files.
+const DefaultRolesDir = "/etc/mesosphere/roles"
+
+// roleMarkers maps role marker file names to DC/OS roles.
+var roleMarkers = []struct {
+	file string
+	role string
+}{
+	{"master", dcos.RoleMaster},
+	{"slave", dcos.RoleAgent},
+	{"slave_public", dcos.RoleAgentPublic},
+}
+
+// DetectRole returns the role of the node based on the marker files in the roles directory.
+func DetectRole(dir string) (string, error) {
+	var roles []string
+	for _, marker := range roleMarkers {
+		_, err := os.Stat(filepath.Join(dir, marker.file))
+		if os.IsNotExist(err) {
+			continue
+		}
+
+		if err != nil {
+			return "", errors.Wrapf(err, "unable to read role marker %s", marker.file)
+		}
+		roles = append(roles, marker.role)
+	}
+
+	switch len(roles) {
+	case 0:
+		return "", errors.Errorf("no role markers found in %s", dir)
+	case 1:
+		return roles[0], nil
+	default:
+		return "", errors.Errorf("multiple role markers found in %s: %v", dir, roles)
+	}
+}
+
+// ResolveRole sets cfg.Role to the role detected from cfg.RolesDir if the role is not set,
+// and warns if an explicitly set role contradicts the detected one.
+func ResolveRole(cfg *CLIConfigFlags) {
+	detected, err := DetectRole(cfg.RolesDir)
+	if err != nil {
+		logrus.Debugf("Unable to detect node role: %s", err)
+		return
+	}
+
+	if cfg.Role == "" {
+		logrus.Debugf("Detected node role %s", detected)
+		cfg.Role = detected
+		return
+	}
+
+	if cfg.Role != detected {
+		logrus.Warnf("Role %s does not match role %s detected from %s", cfg.Role, detected, cfg.RolesDir)
+	}
+}
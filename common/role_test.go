@@ -0,0 +1,63 @@
+package common
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dcos/dcos-go/dcos"
+)
+
+func newRolesDir(t *testing.T, markers ...string) string {
+	dir, err := ioutil.TempDir("", "dcos-checks-roles")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, marker := range markers {
+		if err := ioutil.WriteFile(filepath.Join(dir, marker), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestDetectRole(t *testing.T) {
+	for _, tc := range []struct {
+		markers []string
+		role    string
+		err     bool
+	}{
+		{markers: []string{"master"}, role: dcos.RoleMaster},
+		{markers: []string{"slave"}, role: dcos.RoleAgent},
+		{markers: []string{"slave_public"}, role: dcos.RoleAgentPublic},
+		{markers: nil, err: true},
+		{markers: []string{"slave", "slave_public"}, err: true},
+	} {
+		dir := newRolesDir(t, tc.markers...)
+		role, err := DetectRole(dir)
+		os.RemoveAll(dir)
+
+		if tc.err != (err != nil) || role != tc.role {
+			t.Fatalf("markers %v: expect role %q and error %t. Got %q, %v", tc.markers, tc.role, tc.err, role, err)
+		}
+	}
+}
+
+func TestResolveRole(t *testing.T) {
+	dir := newRolesDir(t, "slave_public")
+	defer os.RemoveAll(dir)
+
+	cfg := &CLIConfigFlags{RolesDir: dir}
+	ResolveRole(cfg)
+	if cfg.Role != dcos.RoleAgentPublic {
+		t.Fatalf("expect detected role %s. Got %s", dcos.RoleAgentPublic, cfg.Role)
+	}
+
+	cfg = &CLIConfigFlags{RolesDir: dir, Role: dcos.RoleMaster}
+	ResolveRole(cfg)
+	if cfg.Role != dcos.RoleMaster {
+		t.Fatalf("expect explicit role %s to be kept. Got %s", dcos.RoleMaster, cfg.Role)
+	}
+}
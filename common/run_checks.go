@@ -0,0 +1,461 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dcos/dcos-checks/client"
+	"github.com/dcos/dcos-checks/constants"
+	"github.com/sirupsen/logrus"
+)
+
+// Task is a single check scheduled for execution by RunChecks.
+type Task struct {
+	// Name is a name used to select the check on the command line, i.e. the registered check name.
+	Name string
+
+	// Label is an optional display name of the task, e.g. a suite entry name. Results are reported
+	// with Label if set, and Name otherwise.
+	Label string
+
+	// Check is the check to execute.
+	Check DCOSChecker
+
+	// Tags is a list of labels of the check.
+	Tags []string
+
+	// Requires is a list of names of the checks which must not fail for this task to be executed.
+	Requires []string
+
+	// Timeout limits the execution time of a single check attempt. Zero means no timeout.
+	Timeout time.Duration
+
+	// Retries is a number of times a check is retried if it returns an error.
+	Retries int
+
+	// Backoff is a delay before the first retry. The delay doubles after each retry.
+	Backoff time.Duration
+}
+
+// Result contains the outcome of a single task executed by RunChecks.
+type Result struct {
+	Name   string
+	ID     string
+	Output string
+	Status int
+	Err    error
+
+	// Start is the time the check was started.
+	Start time.Time
+
+	// Duration is the time it took to run the check, including retries.
+	Duration time.Duration
+
+	// Attempts is a number of times the check was executed.
+	Attempts int
+
+	// Data and Items are structured data and results of individual items reported by the check,
+	// if it implements DetailedChecker.
+	Data  map[string]interface{}
+	Items []ItemResult
+
+	// PerfData contains measurable values reported by the check.
+	PerfData []PerfData
+
+	// Remediation suggests how to fix the problems found by a non-OK check.
+	Remediation []Remediation
+
+	// Skipped is set if the check was not executed because a prerequisite failed or was skipped.
+	Skipped bool
+
+	// Escalated is set if a warning was escalated to a failure based on the check history.
+	Escalated bool
+
+	// Flapping is set if the check status changes frequently based on the check history.
+	Flapping bool
+
+	// NodeIP and Role are set if the check was executed against another node of the cluster.
+	NodeIP string
+	Role   string
+
+	// localIP returns the IP address of the local node. It is resolved at most once per run and
+	// only if an output document of a check executed on the local node reports it.
+	localIP func() string
+}
+
+// LocalIP returns the IP address of the local node the check was executed on, or an empty string
+// if it cannot be detected.
+func (r Result) LocalIP() string {
+	if r.localIP == nil {
+		return ""
+	}
+	return r.localIP()
+}
+
+// DisplayName returns the label of the task, falling back to the check name.
+func (t Task) DisplayName() string {
+	if t.Label != "" {
+		return t.Label
+	}
+	return t.Name
+}
+
+// HasTag returns true if the task has the given tag.
+func (t Task) HasTag(tag string) bool {
+	for _, item := range t.Tags {
+		if item == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// RunChecks executes the given tasks concurrently and returns a result for each of them.
+// The results are returned in the same order as the tasks. A task is executed after all
+// of its prerequisites. If a prerequisite failed, the task is skipped and reported with
+// StatusUnknown.
+func RunChecks(ctx context.Context, cfg *CLIConfigFlags, tasks []Task) []Result {
+	ctx = withRunContext(ctx, cfg)
+	results := make([]Result, len(tasks))
+	prerequisites := taskPrerequisites(tasks)
+	cyclic := cyclicTasks(prerequisites)
+
+	done := make([]chan struct{}, len(tasks))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+
+	var wg sync.WaitGroup
+	for i, task := range tasks {
+		wg.Add(1)
+		go func(i int, task Task) {
+			defer wg.Done()
+			defer close(done[i])
+
+			if cyclic[i] {
+				results[i] = skippedResult(task, "skipped: dependency cycle")
+				return
+			}
+
+			for _, p := range prerequisites[i] {
+				<-done[p]
+				if status := normalizeStatus(results[p].Status); status == constants.StatusFailure || status == constants.StatusUnknown {
+					reason := "failed"
+					if results[p].Skipped {
+						reason = "skipped"
+					}
+					results[i] = skippedResult(task, fmt.Sprintf("skipped: prerequisite %s %s", tasks[p].DisplayName(), reason))
+					return
+				}
+			}
+
+			results[i] = runTask(ctx, cfg, task)
+		}(i, task)
+	}
+	wg.Wait()
+
+	localIP := lazyNodeIP(ctx, cfg)
+	for i := range results {
+		results[i].localIP = localIP
+	}
+	return results
+}
+
+// lazyNodeIP returns a function resolving the IP address of the node described by cfg on the
+// first call, so detect_ip is not executed unless the IP is reported. The node IP is an optional
+// field, an empty string is returned if it cannot be detected.
+func lazyNodeIP(ctx context.Context, cfg *CLIConfigFlags) func() string {
+	var (
+		once sync.Once
+		ip   string
+	)
+
+	return func() string {
+		once.Do(func() {
+			if cfg == nil {
+				return
+			}
+
+			httpClient, ok := client.FromContext(ctx)
+			if !ok {
+				httpClient = &http.Client{}
+			}
+
+			if nodeIP, err := NodeIP(ctx, cfg, httpClient); err != nil {
+				logrus.Debugf("unable to detect node IP: %s", err)
+			} else {
+				ip = nodeIP.String()
+			}
+		})
+		return ip
+	}
+}
+
+// taskPrerequisites returns indexes of the prerequisites of each task. A prerequisite matches
+// every task of the required check, whatever its label. Prerequisites which are not scheduled
+// are ignored.
+func taskPrerequisites(tasks []Task) [][]int {
+	indexes := make(map[string][]int, len(tasks))
+	for i, task := range tasks {
+		indexes[task.Name] = append(indexes[task.Name], i)
+	}
+
+	prerequisites := make([][]int, len(tasks))
+	for i, task := range tasks {
+		for _, name := range task.Requires {
+			for _, p := range indexes[name] {
+				if p != i {
+					prerequisites[i] = append(prerequisites[i], p)
+				}
+			}
+		}
+	}
+	return prerequisites
+}
+
+// cyclicTasks returns true for each task which is a part of a dependency cycle or depends
+// on one, so it would never be executed.
+func cyclicTasks(prerequisites [][]int) []bool {
+	pending := make([]int, len(prerequisites))
+	dependents := make([][]int, len(prerequisites))
+	var ready []int
+	for i, p := range prerequisites {
+		pending[i] = len(p)
+		for _, j := range p {
+			dependents[j] = append(dependents[j], i)
+		}
+		if len(p) == 0 {
+			ready = append(ready, i)
+		}
+	}
+
+	for len(ready) > 0 {
+		i := ready[len(ready)-1]
+		ready = ready[:len(ready)-1]
+		for _, d := range dependents[i] {
+			if pending[d]--; pending[d] == 0 {
+				ready = append(ready, d)
+			}
+		}
+	}
+
+	cyclic := make([]bool, len(prerequisites))
+	for i := range pending {
+		cyclic[i] = pending[i] > 0
+	}
+	return cyclic
+}
+
+// skippedResult returns a result of a task which was not executed.
+func skippedResult(task Task, reason string) Result {
+	return Result{
+		Name:    task.DisplayName(),
+		ID:      task.Check.ID(),
+		Output:  reason,
+		Status:  constants.StatusUnknown,
+		Start:   time.Now(),
+		Skipped: true,
+	}
+}
+
+// defaultRetryBackoff is a delay before the first retry if neither the task nor cfg sets one.
+const defaultRetryBackoff = time.Second
+
+// runTask executes a task, retrying the check with exponential backoff while it returns an error.
+// The timeout flag and retry flags in cfg override the task defaults.
+func runTask(ctx context.Context, cfg *CLIConfigFlags, task Task) Result {
+	timeout, retries, backoff := task.Timeout, task.Retries, task.Backoff
+	if cfg != nil {
+		if cfg.Timeout > 0 {
+			timeout = cfg.Timeout
+		}
+
+		if cfg.Retries > 0 {
+			retries = cfg.Retries
+		}
+
+		if cfg.RetryBackoff > 0 {
+			backoff = cfg.RetryBackoff
+		}
+	}
+
+	if backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+
+	result := Result{
+		Name:  task.DisplayName(),
+		ID:    task.Check.ID(),
+		Start: time.Now(),
+	}
+
+	var checkResult CheckResult
+	for {
+		result.Attempts++
+		checkResult = runAttempt(ctx, cfg, task.Check, timeout)
+		result.Output, result.Status, result.Err = checkResult.Tuple()
+		if result.Err == nil || result.Status == constants.StatusOK || result.Attempts > retries {
+			break
+		}
+
+		logrus.Debugf("%s attempt %d failed: %s. Retrying in %s", result.ID, result.Attempts, result.Err, backoff)
+		select {
+		case <-ctx.Done():
+			result.Duration = time.Since(result.Start)
+			return result
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+
+	result.Duration = time.Since(result.Start)
+	result.Data = checkResult.Data
+	result.Items = checkResult.Items
+	result.PerfData = checkResult.PerfData
+	if normalizeStatus(result.Status) != constants.StatusOK {
+		result.Remediation = checkResult.Remediation
+	}
+	return result
+}
+
+// runAttempt executes a check once, limiting the execution time to the given timeout.
+func runAttempt(ctx context.Context, cfg *CLIConfigFlags, check DCOSChecker, timeout time.Duration) CheckResult {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	return RunCheckResult(ctx, cfg, check)
+}
+
+// WorstStatus returns the most severe status of the given results, following the
+// StatusOK < StatusWarning < StatusFailure < StatusUnknown ordering.
+func WorstStatus(results []Result) int {
+	worst := constants.StatusOK
+	for _, result := range results {
+		if status := normalizeStatus(result.Status); status > worst {
+			worst = status
+		}
+	}
+	return worst
+}
+
+// StatusName returns a human readable name of a check status.
+func StatusName(status int) string {
+	switch normalizeStatus(status) {
+	case constants.StatusOK:
+		return "OK"
+	case constants.StatusWarning:
+		return "WARNING"
+	case constants.StatusFailure:
+		return "FAILURE"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// firstLine returns the first line of a multi-line string.
+func firstLine(s string) string {
+	if i := strings.Index(s, "\n"); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+// resultName returns a name of the check, falling back to the check ID.
+func resultName(result Result) string {
+	if result.Name != "" {
+		return result.Name
+	}
+	return result.ID
+}
+
+// resultMessage returns the check error if any, or the check output.
+func resultMessage(result Result) string {
+	if result.Err != nil {
+		return result.Err.Error()
+	}
+	return result.Output
+}
+
+// normalizeStatus maps exit codes outside of the known statuses to StatusUnknown.
+func normalizeStatus(status int) int {
+	if status < constants.StatusOK || status > constants.StatusUnknown {
+		return constants.StatusUnknown
+	}
+	return status
+}
+
+// PrintSummary writes a one line summary for each result followed by the check output.
+func PrintSummary(w io.Writer, results []Result) {
+	for _, result := range results {
+		fmt.Fprintf(w, "[%s] %s: %s\n", StatusName(result.Status), result.Name, result.ID)
+		if result.Attempts > 1 {
+			fmt.Fprintf(w, "  Attempts: %d\n", result.Attempts)
+		}
+		if result.Escalated {
+			fmt.Fprintln(w, "  Escalated: warning returned for too many consecutive runs")
+		}
+		if result.Flapping {
+			fmt.Fprintln(w, "  Flapping: status changes frequently")
+		}
+		if result.Err != nil {
+			fmt.Fprintf(w, "  Error: %s\n", result.Err)
+		}
+		if result.Output != "" {
+			fmt.Fprintf(w, "  %s\n", strings.Replace(result.Output, "\n", "\n  ", -1))
+		}
+		for _, line := range remediationLines(result) {
+			fmt.Fprintf(w, "  %s\n", line)
+		}
+	}
+	fmt.Fprintf(w, "Overall status: %s\n", StatusName(WorstStatus(results)))
+}
+
+// RunTask is a helper function to run a single task and emit the result.
+func RunTask(ctx context.Context, task Task) {
+	result := ApplyHistory(DCOSConfig, RunChecks(ctx, DCOSConfig, []Task{task}))[0]
+	writeTextfile(DCOSConfig, []Result{result})
+
+	if DCOSConfig.Output != OutputText && DCOSConfig.Output != "" {
+		if err := WriteResults(os.Stdout, DCOSConfig, []Result{result}); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing results: %s\n", err)
+			os.Exit(constants.StatusUnknown)
+		}
+		os.Exit(result.Status)
+	}
+
+	if result.Err != nil {
+		fmt.Fprintf(os.Stderr, "Error executing %s: %s\n", result.ID, result.Err)
+	}
+
+	if result.Output != "" {
+		fmt.Println(result.Output)
+	}
+
+	for _, line := range remediationLines(result) {
+		fmt.Println(line)
+	}
+
+	os.Exit(result.Status)
+}
+
+// RunChecksAndExit is a helper function to run multiple checks, print a summary and exit
+// with the worst status.
+func RunChecksAndExit(ctx context.Context, tasks []Task) {
+	results := ApplyHistory(DCOSConfig, RunChecks(ctx, DCOSConfig, tasks))
+	writeTextfile(DCOSConfig, results)
+
+	if err := WriteResults(os.Stdout, DCOSConfig, results); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing results: %s\n", err)
+		os.Exit(constants.StatusUnknown)
+	}
+	os.Exit(WorstStatus(results))
+}
@@ -0,0 +1,268 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dcos/dcos-checks/constants"
+)
+
+func TestRunChecks(t *testing.T) {
+	tasks := []Task{
+		{Name: "ok", Check: newFakeCheck("all is good", constants.StatusOK, nil)},
+		{Name: "failure", Check: newFakeCheck("", constants.StatusFailure, errors.New("some error"))},
+		{Name: "warning", Check: newFakeCheck("not so good", constants.StatusWarning, nil)},
+	}
+
+	results := RunChecks(context.TODO(), nil, tasks)
+	if len(results) != len(tasks) {
+		t.Fatalf("expect %d results. Got %d", len(tasks), len(results))
+	}
+
+	for i, result := range results {
+		if result.Name != tasks[i].Name {
+			t.Fatalf("expect result %d to be %s. Got %s", i, tasks[i].Name, result.Name)
+		}
+	}
+
+	if results[1].Err == nil || results[1].Err.Error() != "some error" {
+		t.Fatalf("expect error \"some error\". Got %v", results[1].Err)
+	}
+
+	if status := WorstStatus(results); status != constants.StatusFailure {
+		t.Fatalf("expect worst status %d. Got %d", constants.StatusFailure, status)
+	}
+}
+
+func TestRunChecksLocalIP(t *testing.T) {
+	cfg := &CLIConfigFlags{NodeIPStr: "10.0.0.1", Role: "master", Output: OutputJSON}
+	results := RunChecks(context.TODO(), cfg, []Task{{Name: "ok", Check: newFakeCheck("", constants.StatusOK, nil)}})
+	if ip := results[0].LocalIP(); ip != "10.0.0.1" {
+		t.Fatalf("expect local IP 10.0.0.1. Got %q", ip)
+	}
+
+	// the documents use the IP detected by the run instead of detecting it again.
+	cfg.NodeIPStr = ""
+	cfg.DetectIP = "/nonexistent/detect_ip"
+	if docs := NewResultDocuments(cfg, results); docs[0].NodeIP != "10.0.0.1" {
+		t.Fatalf("expect node IP 10.0.0.1. Got %q", docs[0].NodeIP)
+	}
+}
+
+func TestRunChecksLocalIPLazy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dcos-checks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	calls := filepath.Join(dir, "calls")
+	detectIP := filepath.Join(dir, "detect_ip")
+	script := "#!/bin/sh\necho call >> " + calls + "\necho 10.0.0.1\n"
+	if err := ioutil.WriteFile(detectIP, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &CLIConfigFlags{Role: "master", DetectIP: detectIP}
+	results := RunChecks(context.TODO(), cfg, []Task{{Name: "ok", Check: newFakeCheck("", constants.StatusOK, nil)}})
+	PrintSummary(ioutil.Discard, results)
+	if _, err := os.Stat(calls); !os.IsNotExist(err) {
+		t.Fatalf("expect detect_ip not to be executed for text output. Got %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if docs := NewResultDocuments(cfg, results); docs[0].NodeIP != "10.0.0.1" {
+			t.Fatalf("expect node IP 10.0.0.1. Got %q", docs[0].NodeIP)
+		}
+	}
+
+	body, err := ioutil.ReadFile(calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := strings.Count(string(body), "call"); n != 1 {
+		t.Fatalf("expect detect_ip to be executed once. Got %d", n)
+	}
+}
+
+func TestWorstStatus(t *testing.T) {
+	for _, testCase := range []struct {
+		statuses []int
+		expected int
+	}{
+		{
+			statuses: nil,
+			expected: constants.StatusOK,
+		},
+		{
+			statuses: []int{constants.StatusOK, constants.StatusWarning},
+			expected: constants.StatusWarning,
+		},
+		{
+			statuses: []int{constants.StatusUnknown, constants.StatusFailure},
+			expected: constants.StatusUnknown,
+		},
+		{
+			statuses: []int{constants.StatusOK, 127},
+			expected: constants.StatusUnknown,
+		},
+	} {
+		var results []Result
+		for _, status := range testCase.statuses {
+			results = append(results, Result{Status: status})
+		}
+
+		if status := WorstStatus(results); status != testCase.expected {
+			t.Fatalf("expect worst status of %v to be %d. Got %d", testCase.statuses, testCase.expected, status)
+		}
+	}
+}
+
+func TestPrintSummary(t *testing.T) {
+	results := []Result{
+		{Name: "ok", ID: "fakeCheck", Output: "line 1\nline 2", Status: constants.StatusOK},
+		{Name: "failure", ID: "fakeCheck", Status: constants.StatusFailure, Err: errors.New("some error")},
+	}
+
+	var buf bytes.Buffer
+	PrintSummary(&buf, results)
+
+	expected := `[OK] ok: fakeCheck
+  line 1
+  line 2
+[FAILURE] failure: fakeCheck
+  Error: some error
+Overall status: FAILURE
+`
+	if buf.String() != expected {
+		t.Fatalf("expect summary:\n%s\nGot:\n%s", expected, buf.String())
+	}
+}
+
+// flakyCheck fails with an error until it is executed the given number of times.
+type flakyCheck struct {
+	failures int
+	calls    int
+}
+
+func (f *flakyCheck) ID() string {
+	return "flakyCheck"
+}
+
+func (f *flakyCheck) Run(context.Context, *CLIConfigFlags) (string, int, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return "", constants.StatusUnknown, errors.New("connection refused")
+	}
+	return "recovered", constants.StatusOK, nil
+}
+
+func TestRunChecksRetry(t *testing.T) {
+	check := &flakyCheck{failures: 2}
+	results := RunChecks(context.TODO(), nil, []Task{{Name: "flaky", Check: check, Retries: 3, Backoff: time.Millisecond}})
+
+	if results[0].Status != constants.StatusOK || results[0].Attempts != 3 {
+		t.Fatalf("expect success after 3 attempts. Got status %d after %d attempts", results[0].Status, results[0].Attempts)
+	}
+
+	check = &flakyCheck{failures: 5}
+	results = RunChecks(context.TODO(), &CLIConfigFlags{Retries: 1, RetryBackoff: time.Millisecond}, []Task{{Name: "flaky", Check: check}})
+
+	if results[0].Status != constants.StatusUnknown || results[0].Attempts != 2 || results[0].Err == nil {
+		t.Fatalf("expect failure after 2 attempts. Got status %d after %d attempts", results[0].Status, results[0].Attempts)
+	}
+}
+
+// blockingCheck waits until the context is done.
+func TestRunChecksSuiteBackoff(t *testing.T) {
+	// the default flags do not override the backoff of a suite check.
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	cfg := &CLIConfigFlags{NodeIPStr: "127.0.0.1"}
+	check := &flakyCheck{failures: 1}
+	results := RunChecks(ctx, cfg, []Task{{Name: "flaky", Check: check, Retries: 1, Backoff: time.Millisecond}})
+	if results[0].Status != constants.StatusOK || results[0].Attempts != 2 {
+		t.Fatalf("expect the check to recover after a short backoff. Got %+v", results[0])
+	}
+}
+
+type blockingCheck struct{}
+
+func (blockingCheck) ID() string {
+	return "blockingCheck"
+}
+
+func (blockingCheck) Run(ctx context.Context, cfg *CLIConfigFlags) (string, int, error) {
+	<-ctx.Done()
+	return "", constants.StatusUnknown, ctx.Err()
+}
+
+func TestRunChecksTimeout(t *testing.T) {
+	results := RunChecks(context.TODO(), nil, []Task{{Name: "blocking", Check: blockingCheck{}, Timeout: 10 * time.Millisecond}})
+	if results[0].Err != context.DeadlineExceeded {
+		t.Fatalf("expect deadline exceeded error. Got %v", results[0].Err)
+	}
+}
+
+func TestRunChecksPrerequisites(t *testing.T) {
+	tasks := []Task{
+		{Name: "dependent", Requires: []string{"ip"}, Check: newFakeCheck("", constants.StatusOK, nil)},
+		{Name: "ip", Check: newFakeCheck("", constants.StatusFailure, errors.New("no ip"))},
+		{Name: "transitive", Requires: []string{"dependent"}, Check: newFakeCheck("", constants.StatusOK, nil)},
+		{Name: "warning", Check: newFakeCheck("", constants.StatusWarning, nil)},
+		{Name: "satisfied", Requires: []string{"warning", "not-scheduled"}, Check: newFakeCheck("ran", constants.StatusOK, nil)},
+		{Name: "cycle-a", Requires: []string{"cycle-b"}, Check: newFakeCheck("", constants.StatusOK, nil)},
+		{Name: "cycle-b", Requires: []string{"cycle-a"}, Check: newFakeCheck("", constants.StatusOK, nil)},
+	}
+
+	results := RunChecks(context.TODO(), nil, tasks)
+	for _, expected := range []struct {
+		index  int
+		output string
+	}{
+		{0, "skipped: prerequisite ip failed"},
+		{2, "skipped: prerequisite dependent skipped"},
+		{5, "skipped: dependency cycle"},
+		{6, "skipped: dependency cycle"},
+	} {
+		result := results[expected.index]
+		if !result.Skipped || result.Status != constants.StatusUnknown || result.Output != expected.output {
+			t.Fatalf("expect %s to be skipped with %q. Got %+v", result.Name, expected.output, result)
+		}
+	}
+
+	if result := results[4]; result.Skipped || result.Status != constants.StatusOK || result.Output != "ran" {
+		t.Fatalf("expect a check with passed prerequisites to be executed. Got %+v", result)
+	}
+}
+
+func TestRunChecksPrerequisitesLabel(t *testing.T) {
+	tasks := []Task{
+		{Name: "dependent", Label: "renamed-dependent", Requires: []string{"ip"}, Check: newFakeCheck("", constants.StatusOK, nil)},
+		{Name: "ip", Label: "renamed-ip", Check: newFakeCheck("", constants.StatusFailure, errors.New("no ip"))},
+		{Name: "transitive", Requires: []string{"dependent"}, Check: newFakeCheck("", constants.StatusOK, nil)},
+	}
+
+	results := RunChecks(context.TODO(), nil, tasks)
+	for i, expected := range []struct {
+		name   string
+		output string
+	}{
+		{"renamed-dependent", "skipped: prerequisite renamed-ip failed"},
+		{"renamed-ip", ""},
+		{"transitive", "skipped: prerequisite renamed-dependent skipped"},
+	} {
+		if results[i].Name != expected.name || results[i].Output != expected.output {
+			t.Fatalf("expect result %s with output %q. Got %+v", expected.name, expected.output, results[i])
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/dcos/dcos-checks/client"
+	"github.com/dcos/dcos-checks/constants"
+	"github.com/dcos/dcos-go/dcos"
+	"github.com/dcos/dcos-go/dcos/nodeutil"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+type runContextKey struct{}
+
+// RunContext holds state shared by all checks of a single run: one pooled HTTP client and
+// memoized information about the local node and the cluster, so detect_ip is executed and the
+// leader is queried at most once per run.
+type RunContext struct {
+	cfg *CLIConfigFlags
+
+	clientOnce sync.Once
+	client     *http.Client
+	clientErr  error
+
+	nodeInfoOnce sync.Once
+	nodeInfo     nodeutil.NodeInfo
+	nodeInfoErr  error
+
+	ipOnce sync.Once
+	ip     net.IP
+	ipErr  error
+
+	leaderOnce sync.Once
+	leader     bool
+	leaderErr  error
+
+	nodesOnce sync.Once
+	nodes     []Node
+	nodesErr  error
+
+	mesosIDOnce sync.Once
+	mesosID     string
+	mesosIDErr  error
+}
+
+// NewRunContext returns a new run context for the local node described by cfg.
+func NewRunContext(cfg *CLIConfigFlags) *RunContext {
+	if cfg == nil {
+		cfg = &CLIConfigFlags{}
+	}
+	return &RunContext{cfg: cfg}
+}
+
+// Context returns a copy of ctx carrying the run context. An HTTP client already stored in ctx
+// is used as the run client, otherwise the pooled client of the run is stored in the returned
+// context, so checks creating a client with client.NewClientContext share it.
+func (rc *RunContext) Context(ctx context.Context) context.Context {
+	if c, ok := client.FromContext(ctx); ok {
+		rc.clientOnce.Do(func() { rc.client = c })
+	} else if c, err := rc.HTTPClient(); err == nil {
+		ctx = client.NewContext(ctx, c)
+	} else {
+		logrus.Warnf("Unable to create pooled HTTP client, checks create their own clients: %s", err)
+	}
+
+	return context.WithValue(ctx, runContextKey{}, rc)
+}
+
+// RunContextFromContext returns the run context stored in ctx, if any.
+func RunContextFromContext(ctx context.Context) (*RunContext, bool) {
+	rc, ok := ctx.Value(runContextKey{}).(*RunContext)
+	return rc, ok
+}
+
+// withRunContext returns ctx if it carries a run context, or a copy of ctx with a new run context.
+func withRunContext(ctx context.Context, cfg *CLIConfigFlags) context.Context {
+	if _, ok := RunContextFromContext(ctx); ok {
+		return ctx
+	}
+	return NewRunContext(cfg).Context(ctx)
+}
+
+// HTTPClient returns the pooled HTTP client of the run.
+func (rc *RunContext) HTTPClient() (*http.Client, error) {
+	rc.clientOnce.Do(func() {
+		rc.client, rc.clientErr = client.NewPooledClient(rc.cfg.IAMConfig, rc.cfg.CACert)
+	})
+	return rc.client, rc.clientErr
+}
+
+// NodeInfo returns the memoized node info of the local node.
+func (rc *RunContext) NodeInfo() (nodeutil.NodeInfo, error) {
+	rc.nodeInfoOnce.Do(func() {
+		c, err := rc.HTTPClient()
+		if err != nil {
+			rc.nodeInfoErr = err
+			return
+		}
+		rc.nodeInfo, rc.nodeInfoErr = client.NewNodeInfo(c, rc.cfg.Role, rc.cfg.DetectIP, rc.cfg.ForceTLS)
+	})
+	return rc.nodeInfo, rc.nodeInfoErr
+}
+
+// Role returns the DC/OS role of the local node.
+func (rc *RunContext) Role() string {
+	return rc.cfg.Role
+}
+
+// IP returns the IP address of the local node. NodeIPStr takes precedence over detect_ip,
+// which is executed at most once per run.
+func (rc *RunContext) IP() (net.IP, error) {
+	if rc.cfg.NodeIPStr != "" {
+		return rc.cfg.IP(nil)
+	}
+
+	rc.ipOnce.Do(func() {
+		nodeInfo, err := rc.NodeInfo()
+		if err != nil {
+			rc.ipErr = err
+			return
+		}
+		rc.ip, rc.ipErr = nodeInfo.DetectIP()
+	})
+	return rc.ip, rc.ipErr
+}
+
+// IsLeader returns true if the local node is the leading Mesos master, i.e. its IP address is
+// the leader.mesos record of the Mesos DNS running on the node.
+func (rc *RunContext) IsLeader(ctx context.Context) (bool, error) {
+	rc.leaderOnce.Do(func() {
+		if rc.Role() != dcos.RoleMaster {
+			return
+		}
+
+		ip, err := rc.IP()
+		if err != nil {
+			rc.leaderErr = err
+			return
+		}
+
+		leaders, err := ListMasters(ctx, rc.cfg, URLFields{
+			Host: ip.String(),
+			Port: constants.MesosDNSPort,
+			Path: "/v1/hosts/" + dcos.DNSRecordLeader,
+		})
+		if err != nil {
+			rc.leaderErr = errors.Wrap(err, "unable to look up the leader")
+			return
+		}
+
+		for _, leader := range leaders {
+			if ip.Equal(net.ParseIP(leader.IP)) {
+				rc.leader = true
+			}
+		}
+	})
+	return rc.leader, rc.leaderErr
+}
+
+// Nodes returns the masters and agents of the cluster. The nodes are discovered from the local
+// node if it is the leading master, and from leader.mesos otherwise.
+func (rc *RunContext) Nodes(ctx context.Context) ([]Node, error) {
+	rc.nodesOnce.Do(func() {
+		leader := dcos.DNSRecordLeader
+		if isLeader, err := rc.IsLeader(ctx); err != nil {
+			logrus.Debugf("Unable to detect if the node is the leader, using %s: %s", leader, err)
+		} else if isLeader {
+			// the leader is known to be a valid IP at this point.
+			ip, _ := rc.IP()
+			leader = ip.String()
+		}
+
+		rc.nodes, rc.nodesErr = DiscoverNodes(ctx, rc.cfg, leader)
+	})
+	return rc.nodes, rc.nodesErr
+}
+
+// MesosID returns the Mesos ID of the local node. The ID of an agent is looked up in the agents
+// listed by Nodes. The ID of a master is read from its /state endpoint, which is only served by
+// the leading master.
+func (rc *RunContext) MesosID(ctx context.Context) (string, error) {
+	rc.mesosIDOnce.Do(func() {
+		rc.mesosID, rc.mesosIDErr = rc.detectMesosID(ctx)
+	})
+	return rc.mesosID, rc.mesosIDErr
+}
+
+// detectMesosID returns the Mesos ID of the local node.
+func (rc *RunContext) detectMesosID(ctx context.Context) (string, error) {
+	ip, err := rc.IP()
+	if err != nil {
+		return "", err
+	}
+
+	if rc.Role() != dcos.RoleMaster {
+		nodes, err := rc.Nodes(ctx)
+		if err != nil {
+			return "", err
+		}
+
+		for _, node := range nodes {
+			if node.MesosID != "" && ip.Equal(net.ParseIP(node.IP)) {
+				return node.MesosID, nil
+			}
+		}
+		return "", errors.Errorf("agent %s is not registered with the leader", ip)
+	}
+
+	isLeader, err := rc.IsLeader(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if !isLeader {
+		return "", errors.Errorf("master %s is not the leader, its Mesos ID is not available", ip)
+	}
+
+	_, response, err := HTTPRequest(ctx, rc.cfg, URLFields{
+		Host: ip.String(),
+		Port: constants.MesosMasterHTTPPort,
+		Path: "/state",
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var state masterStateResponse
+	if err := json.Unmarshal(response, &state); err != nil {
+		return "", errors.Wrap(err, "unable to unmarshal Mesos state")
+	}
+
+	if state.ID == "" {
+		return "", errors.New("Mesos state has no ID")
+	}
+	return state.ID, nil
+}
+
+// ClusterNodes returns the masters and agents of the cluster, memoized by the run context in ctx
+// if cfg describes the local node of the run, and discovered from leader.mesos otherwise.
+func ClusterNodes(ctx context.Context, cfg *CLIConfigFlags) ([]Node, error) {
+	if rc, ok := RunContextFromContext(ctx); ok && cfg.Role == rc.cfg.Role && cfg.NodeIPStr == rc.cfg.NodeIPStr {
+		return rc.Nodes(ctx)
+	}
+	return DiscoverNodes(ctx, cfg, dcos.DNSRecordLeader)
+}
+
+// NodeIP returns the IP address of the node the checks are executed against. The detected IP
+// is memoized by the run context in ctx if cfg describes the local node of the run.
+func NodeIP(ctx context.Context, cfg *CLIConfigFlags, httpClient *http.Client) (net.IP, error) {
+	rc, ok := RunContextFromContext(ctx)
+	if ok && cfg.NodeIPStr == "" && cfg.Role == rc.cfg.Role && cfg.DetectIP == rc.cfg.DetectIP {
+		return rc.IP()
+	}
+	return cfg.IP(httpClient)
+}
@@ -0,0 +1,157 @@
+package common
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dcos/dcos-checks/client"
+	"github.com/dcos/dcos-checks/constants"
+	"github.com/dcos/dcos-checks/fakecluster"
+	"github.com/dcos/dcos-go/dcos"
+)
+
+// clientCheck records the HTTP client it was executed with.
+type clientCheck struct {
+	mu      *sync.Mutex
+	clients map[*http.Client]bool
+}
+
+func (c clientCheck) ID() string {
+	return "clientCheck"
+}
+
+func (c clientCheck) Run(ctx context.Context, cfg *CLIConfigFlags) (string, int, error) {
+	httpClient, err := client.NewClientContext(ctx, cfg.IAMConfig, cfg.CACert)
+	if err != nil {
+		return "", constants.StatusUnknown, err
+	}
+
+	c.mu.Lock()
+	c.clients[httpClient] = true
+	c.mu.Unlock()
+	return "", constants.StatusOK, nil
+}
+
+func TestRunChecksSharedClient(t *testing.T) {
+	check := clientCheck{mu: &sync.Mutex{}, clients: make(map[*http.Client]bool)}
+	tasks := []Task{{Name: "first", Check: check}, {Name: "second", Check: check}}
+
+	RunChecks(context.TODO(), &CLIConfigFlags{}, tasks)
+	if len(check.clients) != 1 {
+		t.Fatalf("expect all checks to share a client. Got %d clients", len(check.clients))
+	}
+}
+
+func TestRunContextIP(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dcos-checks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	calls := filepath.Join(dir, "calls")
+	detectIP := filepath.Join(dir, "detect_ip")
+	script := "#!/bin/sh\necho call >> " + calls + "\necho 10.0.0.1\n"
+	if err := ioutil.WriteFile(detectIP, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &CLIConfigFlags{Role: "master", DetectIP: detectIP}
+	ctx := NewRunContext(cfg).Context(context.TODO())
+	for i := 0; i < 3; i++ {
+		ip, err := NodeIP(ctx, cfg, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if ip.String() != "10.0.0.1" {
+			t.Fatalf("expect 10.0.0.1. Got %s", ip)
+		}
+	}
+
+	body, err := ioutil.ReadFile(calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := strings.Count(string(body), "call"); n != 1 {
+		t.Fatalf("expect detect_ip to be executed once. Got %d", n)
+	}
+
+	// an explicitly set node IP takes precedence.
+	ip, err := NodeIP(ctx, &CLIConfigFlags{Role: "master", NodeIPStr: "10.0.0.2"}, nil)
+	if err != nil || ip.String() != "10.0.0.2" {
+		t.Fatalf("expect 10.0.0.2. Got %s, %v", ip, err)
+	}
+}
+
+func TestRunContextLeader(t *testing.T) {
+	cluster := fakecluster.New(fakecluster.Healthy())
+	defer cluster.Close()
+
+	for _, testCase := range []struct {
+		cfg      *CLIConfigFlags
+		leader   bool
+		mesosID  string
+		expected string
+	}{
+		{&CLIConfigFlags{Role: dcos.RoleMaster, NodeIPStr: "10.0.0.1"}, true, "10.0.0.1", ""},
+		{&CLIConfigFlags{Role: dcos.RoleMaster, NodeIPStr: "10.0.0.2"}, false, "", "master 10.0.0.2 is not the leader, its Mesos ID is not available"},
+		{&CLIConfigFlags{Role: dcos.RoleAgent, NodeIPStr: "10.0.1.1"}, false, "agent-3", ""},
+		{&CLIConfigFlags{Role: dcos.RoleAgent, NodeIPStr: "10.0.9.9"}, false, "", "agent 10.0.9.9 is not registered with the leader"},
+	} {
+		rc := NewRunContext(testCase.cfg)
+		ctx := rc.Context(cluster.Context(context.TODO()))
+
+		leader, err := rc.IsLeader(ctx)
+		if err != nil || leader != testCase.leader {
+			t.Fatalf("%s: expect leader %t. Got %t, %v", testCase.cfg.NodeIPStr, testCase.leader, leader, err)
+		}
+
+		nodes, err := rc.Nodes(ctx)
+		if err != nil || len(nodes) != 6 {
+			t.Fatalf("%s: expect 6 nodes. Got %+v, %v", testCase.cfg.NodeIPStr, nodes, err)
+		}
+
+		mesosID, err := rc.MesosID(ctx)
+		if testCase.expected != "" {
+			if err == nil || err.Error() != testCase.expected {
+				t.Fatalf("%s: expect error %q. Got %q, %v", testCase.cfg.NodeIPStr, testCase.expected, mesosID, err)
+			}
+			continue
+		}
+
+		if err != nil || mesosID != testCase.mesosID {
+			t.Fatalf("%s: expect Mesos ID %s. Got %q, %v", testCase.cfg.NodeIPStr, testCase.mesosID, mesosID, err)
+		}
+	}
+}
+
+func TestRunContextNodesMemoized(t *testing.T) {
+	cluster := fakecluster.New(fakecluster.Healthy())
+	defer cluster.Close()
+
+	cfg := &CLIConfigFlags{Role: dcos.RoleMaster, NodeIPStr: "10.0.0.1"}
+	ctx := NewRunContext(cfg).Context(cluster.Context(context.TODO()))
+	if nodes, err := ClusterNodes(ctx, cfg); err != nil || len(nodes) != 6 {
+		t.Fatalf("expect 6 nodes. Got %+v, %v", nodes, err)
+	}
+
+	cluster.Update(func(s *fakecluster.Scenario) {
+		s.Nodes = s.Nodes[:5]
+	})
+
+	if nodes, err := ClusterNodes(ctx, cfg); err != nil || len(nodes) != 6 {
+		t.Fatalf("expect the nodes to be discovered once per run. Got %+v, %v", nodes, err)
+	}
+
+	if nodes, err := ClusterNodes(cluster.Context(context.TODO()), cfg); err != nil || len(nodes) != 5 {
+		t.Fatalf("expect the nodes to be discovered without a run context. Got %+v, %v", nodes, err)
+	}
+}
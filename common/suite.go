@@ -0,0 +1,138 @@
+package common
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// SuiteConfig describes a named set of checks defined in dcos-checks-config, e.g.
+//
+//	suites:
+//	  node-poststart:
+//	    description: checks executed after DC/OS components are started
+//	    checks:
+//	      components:
+//	        timeout: 10s
+//	        params:
+//	          exclude: [dcos-checks-poststart.service]
+//	      version:
+//	        roles: [master]
+type SuiteConfig struct {
+	// Description is an optional description of the suite.
+	Description string `mapstructure:"description"`
+
+	// Checks maps an entry name to a check configuration. An entry name is also
+	// a check name unless the check is explicitly set in the entry. Results are
+	// reported with the entry name.
+	Checks map[string]SuiteCheckConfig `mapstructure:"checks"`
+}
+
+// SuiteCheckConfig describes a single check of a suite.
+type SuiteCheckConfig struct {
+	// Check is a registered check name. Defaults to the entry name.
+	Check string `mapstructure:"check"`
+
+	// Roles overrides the roles the check is applicable to.
+	Roles []string `mapstructure:"roles"`
+
+	// Timeout overrides the check default timeout.
+	Timeout time.Duration `mapstructure:"timeout"`
+
+	// Retries is a number of times the check is retried on error.
+	Retries int `mapstructure:"retries"`
+
+	// Backoff is a delay before the first retry.
+	Backoff time.Duration `mapstructure:"backoff"`
+
+	// Args is a list of positional check arguments.
+	Args []string `mapstructure:"args"`
+
+	// Params maps check parameter names to their values.
+	Params map[string]interface{} `mapstructure:"params"`
+}
+
+// checkName returns the registered check name of the entry.
+func (c SuiteCheckConfig) checkName(entry string) string {
+	if c.Check != "" {
+		return c.Check
+	}
+	return entry
+}
+
+// appliesTo returns true if the suite check should be executed on a node with the given role.
+// If the role is not set, every check is applicable.
+func (c SuiteCheckConfig) appliesTo(spec CheckSpec, role string) bool {
+	if role == "" {
+		return true
+	}
+
+	if len(c.Roles) > 0 {
+		for _, r := range c.Roles {
+			if r == role {
+				return true
+			}
+		}
+		return false
+	}
+
+	// checks without roles are only executed when selected explicitly, as they are here.
+	return len(spec.Roles) == 0 || spec.HasRole(role)
+}
+
+// Tasks returns tasks for all suite checks applicable to the given role, sorted by entry name.
+func (s SuiteConfig) Tasks(role string) ([]Task, error) {
+	entries := make([]string, 0, len(s.Checks))
+	for entry := range s.Checks {
+		entries = append(entries, entry)
+	}
+	sort.Strings(entries)
+
+	var tasks []Task
+	for _, entry := range entries {
+		checkCfg := s.Checks[entry]
+		spec, ok := LookupCheck(checkCfg.checkName(entry))
+		if !ok {
+			return nil, errors.Errorf("%s: unknown check %s", entry, checkCfg.checkName(entry))
+		}
+
+		if !checkCfg.appliesTo(spec, role) {
+			continue
+		}
+
+		task, err := spec.NewTask(checkCfg.Args, checkCfg.Params)
+		if err != nil {
+			return nil, errors.Wrap(err, entry)
+		}
+
+		// the check name is kept as the key of the prerequisites of other checks.
+		task.Label = entry
+		if checkCfg.Timeout > 0 {
+			task.Timeout = checkCfg.Timeout
+		}
+		task.Retries = checkCfg.Retries
+		task.Backoff = checkCfg.Backoff
+		tasks = append(tasks, task)
+	}
+
+	return tasks, nil
+}
+
+// paramValue converts a parameter value from a config file to a flag value.
+func paramValue(value interface{}) string {
+	switch v := value.(type) {
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, fmt.Sprint(item))
+		}
+		return strings.Join(items, ",")
+	case []string:
+		return strings.Join(v, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,93 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dcos/dcos-checks/constants"
+	"github.com/spf13/pflag"
+)
+
+func init() {
+	RegisterCheck(CheckSpec{
+		Name:  "test-suite-check",
+		Roles: []string{"master"},
+		Flags: func(flags *pflag.FlagSet) {
+			flags.StringSlice("items", nil, "items to print")
+		},
+		New: func(flags *pflag.FlagSet, args []string) (DCOSChecker, error) {
+			items, err := flags.GetStringSlice("items")
+			if err != nil {
+				return nil, err
+			}
+			return newFakeCheck(fmt.Sprint(append(items, args...)), constants.StatusOK, nil), nil
+		},
+	})
+}
+
+func TestSuiteTasks(t *testing.T) {
+	suite := SuiteConfig{
+		Checks: map[string]SuiteCheckConfig{
+			"test-suite-check": {
+				Timeout: 5 * time.Second,
+				Params: map[string]interface{}{
+					"items": []interface{}{"a", "b"},
+				},
+			},
+			"agent-only": {
+				Check: "test-suite-check",
+				Roles: []string{"agent"},
+				Args:  []string{"c"},
+			},
+		},
+	}
+
+	tasks, err := suite.Tasks("master")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tasks) != 1 || tasks[0].Name != "test-suite-check" || tasks[0].Timeout != 5*time.Second {
+		t.Fatalf("unexpected tasks %+v", tasks)
+	}
+
+	output, _, _ := tasks[0].Check.Run(context.TODO(), nil)
+	if output != "[a b]" {
+		t.Fatalf("expect params to be applied. Got %s", output)
+	}
+
+	tasks, err = suite.Tasks("agent")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tasks) != 1 || tasks[0].Name != "test-suite-check" || tasks[0].DisplayName() != "agent-only" {
+		t.Fatalf("unexpected tasks %+v", tasks)
+	}
+
+	output, _, _ = tasks[0].Check.Run(context.TODO(), nil)
+	if output != "[c]" {
+		t.Fatalf("expect args to be passed. Got %s", output)
+	}
+}
+
+func TestSuiteTasksInvalid(t *testing.T) {
+	for _, suite := range []SuiteConfig{
+		{
+			Checks: map[string]SuiteCheckConfig{"no-such-check": {}},
+		},
+		{
+			Checks: map[string]SuiteCheckConfig{
+				"test-suite-check": {
+					Params: map[string]interface{}{"no-such-param": 1},
+				},
+			},
+		},
+	} {
+		if _, err := suite.Tasks("master"); err == nil {
+			t.Fatalf("expect error for suite %+v", suite)
+		}
+	}
+}
@@ -0,0 +1,411 @@
+// Package fakecluster provides an in-process fake DC/OS cluster for testing checks end-to-end.
+//
+// A Cluster serves Mesos master, Mesos DNS, dcos-diagnostics (including the cluster health
+// aggregated on masters) and adminrouter endpoints of every node of a Scenario from a single
+// httptest server. Cluster.Client returns an HTTP client which routes connections to any node
+// address to the server, so checks are executed unmodified:
+//
+//	c := fakecluster.New(fakecluster.Healthy())
+//	defer c.Close()
+//
+//	output, status, err := check.Run(c.Context(context.TODO()), cfg)
+//
+// Plain HTTP is served only, checks must not be executed with ForceTLS.
+package fakecluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/dcos/dcos-checks/client"
+	"github.com/dcos/dcos-checks/constants"
+	"github.com/dcos/dcos-go/dcos"
+)
+
+// dcosDiagnosticsMasterPort is a port dcos-diagnostics listens on master nodes.
+const dcosDiagnosticsMasterPort = 1050
+
+// Cluster is a running fake cluster.
+type Cluster struct {
+	server *httptest.Server
+
+	mu       sync.RWMutex
+	scenario *Scenario
+}
+
+// New starts a fake cluster serving the given scenario.
+func New(scenario *Scenario) *Cluster {
+	c := &Cluster{scenario: scenario}
+	c.server = httptest.NewServer(http.HandlerFunc(c.serveHTTP))
+	return c
+}
+
+// Close shuts down the fake cluster.
+func (c *Cluster) Close() {
+	c.server.Close()
+}
+
+// Update modifies the scenario of a running cluster.
+func (c *Cluster) Update(f func(*Scenario)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	f(c.scenario)
+}
+
+// Client returns an HTTP client which connects to the fake cluster for any node address.
+// Connections to unknown nodes, unreachable nodes and ports a node does not listen on are refused.
+func (c *Cluster) Client() *http.Client {
+	dialer := &net.Dialer{}
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				if err := c.dialError(addr); err != nil {
+					return nil, &net.OpError{Op: "dial", Net: network, Err: err}
+				}
+				return dialer.DialContext(ctx, network, c.server.Listener.Addr().String())
+			},
+		},
+	}
+}
+
+// Context returns a copy of ctx with the fake cluster client, used by the checks instead of a new client.
+func (c *Cluster) Context(ctx context.Context) context.Context {
+	return client.NewContext(ctx, c.Client())
+}
+
+// dialError returns an error if a connection to the address must fail.
+func (c *Cluster) dialError(addr string) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return err
+	}
+
+	node, err := c.resolve(host)
+	if err != nil {
+		return err
+	}
+
+	if node.Unreachable || !listens(node.Role, port) {
+		return syscall.ECONNREFUSED
+	}
+	return nil
+}
+
+// resolve returns a node by IP or one of the Mesos DNS records leader.mesos and master.mesos.
+func (c *Cluster) resolve(host string) (*Node, error) {
+	if host == dcos.DNSRecordLeader || host == "master.mesos" {
+		if c.scenario.Leader == "" {
+			return nil, &net.DNSError{Err: "no such host", Name: host}
+		}
+		host = c.scenario.Leader
+	}
+
+	node, ok := c.scenario.Node(host)
+	if !ok {
+		return nil, syscall.EHOSTUNREACH
+	}
+	return node, nil
+}
+
+// listens returns true if a node with the role listens on the port.
+func listens(role string, port int) bool {
+	if role == dcos.RoleMaster {
+		switch port {
+		case 80, dcosDiagnosticsMasterPort, constants.MesosMasterHTTPPort, constants.MesosDNSPort:
+			return true
+		}
+		return false
+	}
+
+	switch port {
+	case constants.MesosAgentHTTPPort, constants.AdminrouterAgentHTTPPort:
+		return true
+	}
+	return false
+}
+
+func (c *Cluster) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	host, portStr, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		host, portStr = r.Host, "80"
+	}
+	port, _ := strconv.Atoi(portStr)
+
+	node, err := c.resolve(host)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	switch {
+	case node.Role == dcos.RoleMaster && port == constants.MesosMasterHTTPPort:
+		c.serveMesosMaster(w, r, node)
+	case node.Role == dcos.RoleMaster && port == constants.MesosDNSPort:
+		c.serveMesosDNS(w, r)
+	case node.Role != dcos.RoleMaster && port == constants.MesosAgentHTTPPort:
+		c.serveMesosAgent(w, r, node)
+	default:
+		// dcos-diagnostics on masters and adminrouter on all nodes.
+		c.serveAdminrouter(w, r, node)
+	}
+}
+
+// serveMesosMaster serves Mesos master endpoints. A non-leading master redirects to the leader
+// and returns 503 if there is no leader.
+func (c *Cluster) serveMesosMaster(w http.ResponseWriter, r *http.Request, node *Node) {
+	switch r.URL.Path {
+	case "/metrics/snapshot":
+		writeJSON(w, node.Metrics)
+		return
+	case "/state", "/slaves":
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	if c.scenario.Leader == "" {
+		http.Error(w, "No leader elected", http.StatusServiceUnavailable)
+		return
+	}
+
+	if node.IP != c.scenario.Leader {
+		location := fmt.Sprintf("http://%s%s", net.JoinHostPort(c.scenario.Leader, strconv.Itoa(constants.MesosMasterHTTPPort)), r.URL.Path)
+		http.Redirect(w, r, location, http.StatusTemporaryRedirect)
+		return
+	}
+
+	if r.URL.Path == "/slaves" {
+		writeJSON(w, map[string]interface{}{
+			"slaves":           c.slaves(false),
+			"recovered_slaves": c.slaves(true),
+		})
+		return
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"version":  DefaultVersion,
+		"id":       node.IP,
+		"pid":      fmt.Sprintf("master@%s:%d", node.IP, constants.MesosMasterHTTPPort),
+		"hostname": node.IP,
+		"leader":   fmt.Sprintf("master@%s:%d", c.scenario.Leader, constants.MesosMasterHTTPPort),
+		"leader_info": map[string]interface{}{
+			"hostname": c.scenario.Leader,
+			"port":     constants.MesosMasterHTTPPort,
+		},
+		"slaves": c.slaves(false),
+	})
+}
+
+// slaves returns the registered or the recovered agents in Mesos /slaves format.
+func (c *Cluster) slaves(recovered bool) []map[string]interface{} {
+	slaves := []map[string]interface{}{}
+	for i, node := range c.scenario.Nodes {
+		if node.Role == dcos.RoleMaster || node.Recovered != recovered {
+			continue
+		}
+
+		slave := map[string]interface{}{
+			"id":       fmt.Sprintf("agent-%d", i),
+			"hostname": node.IP,
+			"port":     constants.MesosAgentHTTPPort,
+		}
+
+		if !recovered {
+			slave["pid"] = fmt.Sprintf("slave(1)@%s:%d", node.IP, constants.MesosAgentHTTPPort)
+			slave["active"] = true
+		}
+
+		if node.Role == dcos.RoleAgentPublic {
+			slave["attributes"] = map[string]string{"public_ip": "true"}
+		}
+		slaves = append(slaves, slave)
+	}
+	return slaves
+}
+
+// serveMesosDNS serves Mesos DNS /v1/hosts/<name> records.
+func (c *Cluster) serveMesosDNS(w http.ResponseWriter, r *http.Request) {
+	type record struct {
+		Host string `json:"host"`
+		IP   string `json:"ip"`
+	}
+
+	var nodes []Node
+	switch r.URL.Path {
+	case "/v1/hosts/master.mesos":
+		nodes = c.scenario.NodesWithRole(dcos.RoleMaster)
+	case "/v1/hosts/leader.mesos":
+		if leader, ok := c.scenario.Node(c.scenario.Leader); ok {
+			nodes = []Node{*leader}
+		}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	records := []record{}
+	for _, node := range nodes {
+		records = append(records, record{Host: r.URL.Path[len("/v1/hosts/"):] + ".", IP: node.IP})
+	}
+	writeJSON(w, records)
+}
+
+// serveMesosAgent serves Mesos agent endpoints.
+func (c *Cluster) serveMesosAgent(w http.ResponseWriter, r *http.Request, node *Node) {
+	if r.URL.Path != "/metrics/snapshot" {
+		http.NotFound(w, r)
+		return
+	}
+	writeJSON(w, node.Metrics)
+}
+
+// serveAdminrouter serves dcos-diagnostics and DC/OS metadata endpoints.
+func (c *Cluster) serveAdminrouter(w http.ResponseWriter, r *http.Request, node *Node) {
+	if node.Role == dcos.RoleMaster && strings.HasPrefix(r.URL.Path, "/system/health/v1/") {
+		c.serveClusterHealth(w, r)
+		return
+	}
+
+	switch r.URL.Path {
+	case "/system/health/v1":
+		writeJSON(w, map[string]interface{}{"units": units(node)})
+	case "/dcos-metadata/dcos-version.json":
+		if node.InvalidVersion {
+			w.Header().Set("Content-Type", "text/html")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("<html><body>502 Bad Gateway</body></html>"))
+			return
+		}
+		writeJSON(w, map[string]string{
+			"version":           node.Version,
+			"dcos-image-commit": node.ImageCommit,
+			"bootstrap-id":      node.BootstrapID,
+		})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serveClusterHealth serves the cluster health aggregated by dcos-diagnostics on masters:
+// /system/health/v1/nodes, /system/health/v1/units and /system/health/v1/units/<unit>/nodes.
+// Unreachable nodes are reported with unknown health.
+func (c *Cluster) serveClusterHealth(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/system/health/v1/")
+	switch {
+	case path == "nodes":
+		nodes := []map[string]interface{}{}
+		for i := range c.scenario.Nodes {
+			node := &c.scenario.Nodes[i]
+			health := constants.StatusOK
+			for _, unit := range node.Units {
+				health = worstHealth(health, unit.Health)
+			}
+			nodes = append(nodes, nodeHealth(node, health, ""))
+		}
+		writeJSON(w, map[string]interface{}{"nodes": nodes})
+	case path == "units":
+		var ids []string
+		aggregated := make(map[string]map[string]interface{})
+		for i := range c.scenario.Nodes {
+			for _, unit := range units(&c.scenario.Nodes[i]) {
+				id := unit["id"].(string)
+				if _, ok := aggregated[id]; !ok {
+					ids = append(ids, id)
+					aggregated[id] = unit
+				}
+				health := unit["health"].(int)
+				if c.scenario.Nodes[i].Unreachable {
+					health = constants.StatusUnknown
+				}
+				aggregated[id]["health"] = worstHealth(aggregated[id]["health"].(int), health)
+				delete(aggregated[id], "output")
+			}
+		}
+
+		result := []map[string]interface{}{}
+		for _, id := range ids {
+			result = append(result, aggregated[id])
+		}
+		writeJSON(w, map[string]interface{}{"units": result})
+	case strings.HasPrefix(path, "units/") && strings.HasSuffix(path, "/nodes"):
+		id := strings.TrimSuffix(strings.TrimPrefix(path, "units/"), "/nodes")
+		nodes := []map[string]interface{}{}
+		for i := range c.scenario.Nodes {
+			node := &c.scenario.Nodes[i]
+			for _, unit := range node.Units {
+				if unit.ID == id {
+					nodes = append(nodes, nodeHealth(node, unit.Health, unit.Output))
+				}
+			}
+		}
+
+		if len(nodes) == 0 {
+			http.NotFound(w, r)
+			return
+		}
+		writeJSON(w, map[string]interface{}{"nodes": nodes})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// nodeHealth returns the node health in dcos-diagnostics format.
+func nodeHealth(node *Node, health int, output string) map[string]interface{} {
+	if node.Unreachable {
+		health, output = constants.StatusUnknown, "node is unreachable"
+	}
+	return map[string]interface{}{
+		"host_ip": node.IP,
+		"health":  health,
+		"role":    node.Role,
+		"output":  output,
+	}
+}
+
+// worstHealth returns the worse of two health statuses.
+func worstHealth(a, b int) int {
+	if b > a {
+		return b
+	}
+	return a
+}
+
+// units returns the node units in dcos-diagnostics format.
+func units(node *Node) []map[string]interface{} {
+	units := []map[string]interface{}{}
+	for _, unit := range node.Units {
+		units = append(units, map[string]interface{}{
+			"id":          unit.ID,
+			"name":        unit.Name,
+			"description": unit.Description,
+			"help":        unit.Help,
+			"output":      unit.Output,
+			"health":      unit.Health,
+		})
+	}
+	return units
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,105 @@
+package fakecluster
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func get(t *testing.T, c *Cluster, url string, v interface{}) (int, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := c.Client().Do(req.WithContext(context.TODO()))
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if v != nil && resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatalf("unable to decode %s: %s", url, err)
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+func TestClusterRouting(t *testing.T) {
+	c := New(Healthy())
+	defer c.Close()
+
+	var masters []struct {
+		IP string `json:"ip"`
+	}
+	if code, err := get(t, c, "http://leader.mesos:8123/v1/hosts/master.mesos", &masters); err != nil || code != http.StatusOK {
+		t.Fatalf("expect 200. Got %d, %v", code, err)
+	}
+
+	if len(masters) != 3 {
+		t.Fatalf("expect 3 masters. Got %+v", masters)
+	}
+
+	// non-leading masters redirect to the leader.
+	var slaves struct {
+		Slaves []struct {
+			Hostname string `json:"hostname"`
+		} `json:"slaves"`
+	}
+	if code, err := get(t, c, "http://10.0.0.2:5050/slaves", &slaves); err != nil || code != http.StatusOK {
+		t.Fatalf("expect 200. Got %d, %v", code, err)
+	}
+
+	if len(slaves.Slaves) != 3 {
+		t.Fatalf("expect 3 agents. Got %+v", slaves)
+	}
+
+	var version struct {
+		Version string `json:"version"`
+	}
+	if code, err := get(t, c, "http://10.0.1.1:61001/dcos-metadata/dcos-version.json", &version); err != nil || code != http.StatusOK {
+		t.Fatalf("expect 200. Got %d, %v", code, err)
+	}
+
+	if version.Version != DefaultVersion {
+		t.Fatalf("expect version %s. Got %s", DefaultVersion, version.Version)
+	}
+
+	for _, url := range []string{
+		"http://10.0.1.1:5050/metrics/snapshot",
+		"http://10.9.9.9:5051/metrics/snapshot",
+	} {
+		if _, err := get(t, c, url, nil); err == nil {
+			t.Fatalf("expect connection to %s to fail", url)
+		}
+	}
+}
+
+func TestClusterLeaderless(t *testing.T) {
+	c := New(Leaderless())
+	defer c.Close()
+
+	if _, err := get(t, c, "http://leader.mesos:5050/state", nil); err == nil {
+		t.Fatal("expect leader.mesos not to resolve")
+	}
+
+	if code, err := get(t, c, "http://10.0.0.2:5050/slaves", nil); err != nil || code != http.StatusServiceUnavailable {
+		t.Fatalf("expect 503 without a leader. Got %d, %v", code, err)
+	}
+}
+
+func TestClusterUpdate(t *testing.T) {
+	c := New(Healthy())
+	defer c.Close()
+
+	c.Update(func(s *Scenario) {
+		node, _ := s.Node("10.0.1.2")
+		node.Unreachable = true
+	})
+
+	if _, err := get(t, c, "http://10.0.1.2:61001/system/health/v1", nil); err == nil {
+		t.Fatal("expect an unreachable node to refuse connections")
+	}
+}
@@ -0,0 +1,222 @@
+package fakecluster
+
+import (
+	"github.com/dcos/dcos-checks/constants"
+	"github.com/dcos/dcos-go/dcos"
+)
+
+// DefaultVersion is a DC/OS version reported by the nodes of the predefined scenarios.
+const DefaultVersion = "1.10.0"
+
+// DefaultImageCommit and DefaultBootstrapID are reported along with DefaultVersion.
+const (
+	DefaultImageCommit = "0123456789abcdef"
+	DefaultBootstrapID = "fedcba9876543210"
+)
+
+// Unit is a systemd unit reported by dcos-diagnostics.
+type Unit struct {
+	ID          string
+	Name        string
+	Description string
+	Help        string
+	Output      string
+	Health      int
+}
+
+// Node is a node of a fake cluster.
+type Node struct {
+	IP   string
+	Role string
+
+	// Version, ImageCommit and BootstrapID are served by /dcos-metadata/dcos-version.json.
+	Version     string
+	ImageCommit string
+	BootstrapID string
+
+	// InvalidVersion nodes serve an HTML error page on /dcos-metadata/dcos-version.json.
+	InvalidVersion bool
+
+	// Units are served by the dcos-diagnostics health endpoint /system/health/v1.
+	Units []Unit
+
+	// Metrics are served by the Mesos endpoint /metrics/snapshot.
+	Metrics map[string]float64
+
+	// Unreachable nodes refuse all connections.
+	Unreachable bool
+
+	// Recovered agents are listed by the leader as recovered_slaves instead of slaves.
+	Recovered bool
+}
+
+// Scenario describes the state of a fake cluster.
+type Scenario struct {
+	Name string
+
+	// Leader is an IP of the leading master. leader.mesos cannot be resolved if the leader is empty.
+	Leader string
+
+	Nodes []Node
+}
+
+// Node returns a node by IP.
+func (s *Scenario) Node(ip string) (*Node, bool) {
+	for i := range s.Nodes {
+		if s.Nodes[i].IP == ip {
+			return &s.Nodes[i], true
+		}
+	}
+	return nil, false
+}
+
+// NodesWithRole returns all nodes with the given role.
+func (s *Scenario) NodesWithRole(role string) []Node {
+	var nodes []Node
+	for _, node := range s.Nodes {
+		if node.Role == role {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// Healthy returns a scenario of a healthy cluster with 3 masters, 2 private agents and a public agent.
+func Healthy() *Scenario {
+	s := &Scenario{
+		Name:   "healthy",
+		Leader: "10.0.0.1",
+	}
+
+	for _, node := range []struct {
+		ip   string
+		role string
+	}{
+		{"10.0.0.1", dcos.RoleMaster},
+		{"10.0.0.2", dcos.RoleMaster},
+		{"10.0.0.3", dcos.RoleMaster},
+		{"10.0.1.1", dcos.RoleAgent},
+		{"10.0.1.2", dcos.RoleAgent},
+		{"10.0.2.1", dcos.RoleAgentPublic},
+	} {
+		s.Nodes = append(s.Nodes, newNode(node.ip, node.role, node.ip == s.Leader))
+	}
+	return s
+}
+
+// MixedVersions returns a scenario of a stalled upgrade with nodes running 3 different DC/OS versions.
+func MixedVersions() *Scenario {
+	s := Healthy()
+	s.Name = "mixed-versions"
+	for i := range s.Nodes {
+		switch s.Nodes[i].IP {
+		case "10.0.1.1":
+			s.Nodes[i].Version = "1.9.4"
+			s.Nodes[i].ImageCommit = "1a2b3c4d5e6f7a8b"
+			s.Nodes[i].BootstrapID = "8b7a6f5e4d3c2b1a"
+		case "10.0.2.1":
+			s.Nodes[i].Version = "1.11.0"
+			s.Nodes[i].ImageCommit = "9f8e7d6c5b4a3928"
+			s.Nodes[i].BootstrapID = "2839a4b5c6d7e8f9"
+		}
+	}
+	return s
+}
+
+// InconsistentBootstrap returns a scenario of a cluster with an agent on DC/OS version
+// DefaultVersion installed from a different bootstrap tarball on 10.0.1.2.
+func InconsistentBootstrap() *Scenario {
+	s := Healthy()
+	s.Name = "inconsistent-bootstrap"
+
+	node, _ := s.Node("10.0.1.2")
+	node.BootstrapID = "0f1e2d3c4b5a6978"
+	return s
+}
+
+// UnhealthyUnit returns a scenario of a cluster with a failed Mesos agent unit on 10.0.1.1.
+func UnhealthyUnit() *Scenario {
+	s := Healthy()
+	s.Name = "unhealthy-unit"
+
+	node, _ := s.Node("10.0.1.1")
+	for i := range node.Units {
+		if node.Units[i].ID == "dcos-mesos-slave.service" {
+			node.Units[i].Health = constants.StatusWarning
+			node.Units[i].Output = "dcos-mesos-slave.service: main process exited, code=exited, status=1/FAILURE"
+		}
+	}
+	node.Metrics["slave/registered"] = 0
+	return s
+}
+
+// Leaderless returns a scenario of a cluster without an elected Mesos leader.
+func Leaderless() *Scenario {
+	s := Healthy()
+	s.Name = "leaderless"
+	s.Leader = ""
+	for i := range s.Nodes {
+		if s.Nodes[i].Role == dcos.RoleMaster {
+			s.Nodes[i].Metrics["master/elected"] = 0
+		}
+	}
+	return s
+}
+
+// Scenarios returns all predefined scenarios by name.
+func Scenarios() map[string]func() *Scenario {
+	return map[string]func() *Scenario{
+		"healthy":                Healthy,
+		"mixed-versions":         MixedVersions,
+		"inconsistent-bootstrap": InconsistentBootstrap,
+		"unhealthy-unit":         UnhealthyUnit,
+		"leaderless":             Leaderless,
+	}
+}
+
+// newNode returns a healthy node with the default units and metrics of the role.
+func newNode(ip, role string, leader bool) Node {
+	node := Node{
+		IP:          ip,
+		Role:        role,
+		Version:     DefaultVersion,
+		ImageCommit: DefaultImageCommit,
+		BootstrapID: DefaultBootstrapID,
+		Metrics:     make(map[string]float64),
+	}
+
+	var units []Unit
+	if role == dcos.RoleMaster {
+		units = []Unit{
+			{ID: "dcos-adminrouter.service", Name: "Admin Router Master", Description: "exposes a unified control plane proxy for components and services using NGINX"},
+			{ID: "dcos-diagnostics.service", Name: "DC/OS Diagnostics Master", Description: "aggregates and exposes component health"},
+			{ID: "dcos-mesos-dns.service", Name: "Mesos DNS", Description: "DNS-based service discovery for Mesos"},
+			{ID: "dcos-mesos-master.service", Name: "Mesos Master", Description: "distributed systems kernel"},
+		}
+
+		node.Metrics["registrar/log/recovered"] = 1
+		node.Metrics["master/elected"] = 0
+		if leader {
+			node.Metrics["master/elected"] = 1
+		}
+	} else {
+		slaveUnit := "dcos-mesos-slave.service"
+		if role == dcos.RoleAgentPublic {
+			slaveUnit = "dcos-mesos-slave-public.service"
+		}
+
+		units = []Unit{
+			{ID: "dcos-adminrouter-agent.service", Name: "Admin Router Agent", Description: "exposes a unified control plane proxy for components and services using NGINX"},
+			{ID: "dcos-diagnostics.socket", Name: "DC/OS Diagnostics Agent Socket", Description: "socket for DC/OS Diagnostics Agent"},
+			{ID: slaveUnit, Name: "Mesos Agent", Description: "distributed systems kernel agent"},
+		}
+
+		node.Metrics["slave/registered"] = 1
+	}
+
+	for _, unit := range units {
+		unit.Health = constants.StatusOK
+		node.Units = append(node.Units, unit)
+	}
+	return node
+}
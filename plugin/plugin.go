@@ -0,0 +1,360 @@
+// Package plugin wraps external executables as DC/OS checks.
+//
+// A plugin is an executable file in a plugin directory. An optional sidecar manifest
+// <name>.yaml next to the executable <name> or <name>.<ext> describes the check:
+//
+//	name: ntp
+//	description: Check NTP peers
+//	roles: [master, agent, agent_public]
+//	tags: [node]
+//	requires: [ip]
+//	timeout: 10s
+//	params:
+//	  - name: max-offset
+//	    default: "100ms"
+//	    usage: maximum allowed offset
+//
+// The plugin is executed with the check arguments. The global flags and the check parameters
+// are passed as DCOS_CHECKS_* environment variables. The exit code follows the check status
+// convention: 0 OK, 1 warning, 2 failure, 3 unknown. Any other exit code is reported as unknown.
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/dcos/dcos-checks/common"
+	"github.com/dcos/dcos-checks/constants"
+	"github.com/dcos/dcos-go/exec"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v2"
+)
+
+// DefaultDir is a directory plugins are discovered in by default.
+const DefaultDir = "/opt/mesosphere/etc/dcos-checks/plugins"
+
+// envPrefix is a prefix of the environment variables passed to plugins.
+const envPrefix = "DCOS_CHECKS_"
+
+// pluginTag is added to the tags of every plugin check.
+const pluginTag = "plugin"
+
+// pluginOwner is the uid plugin files must be owned by. Tests override it to run plugins
+// owned by the current user.
+var pluginOwner = 0
+
+// Manifest describes a plugin check.
+type Manifest struct {
+	Name          string   `yaml:"name"`
+	Description   string   `yaml:"description"`
+	Long          string   `yaml:"long"`
+	Roles         []string `yaml:"roles"`
+	Tags          []string `yaml:"tags"`
+	Requires      []string `yaml:"requires"`
+	Timeout       string   `yaml:"timeout"`
+	ClusterAccess bool     `yaml:"cluster_access"`
+	Params        []Param  `yaml:"params"`
+
+	// Remediation is reported if the plugin returns a non-OK status.
+	Remediation []common.Remediation `yaml:"remediation"`
+}
+
+// Param is a plugin parameter, exposed as a check flag.
+type Param struct {
+	Name    string `yaml:"name"`
+	Default string `yaml:"default"`
+	Usage   string `yaml:"usage"`
+}
+
+// pluginCheck executes an external plugin.
+type pluginCheck struct {
+	Name   string
+	Path   string
+	Args   []string
+	Params map[string]string
+
+	remediation []common.Remediation
+}
+
+// Discover returns a check spec for each plugin found in the given directories.
+// Directories which do not exist are skipped. Unreadable directories, unsafe plugin files and
+// plugins with an invalid manifest are logged and skipped, so a broken plugin does not disable
+// other checks.
+func Discover(dirs []string) []common.CheckSpec {
+	var specs []common.CheckSpec
+	for _, dir := range dirs {
+		files, err := ioutil.ReadDir(dir)
+		if os.IsNotExist(err) {
+			logrus.Debugf("Plugin directory %s does not exist", dir)
+			continue
+		}
+
+		if err != nil {
+			logrus.Warnf("Skipping plugin directory %s: %s", dir, err)
+			continue
+		}
+
+		for _, file := range files {
+			if !isPlugin(file) {
+				continue
+			}
+
+			path := filepath.Join(dir, file.Name())
+			if err := checkPermissions(file); err != nil {
+				logrus.Warnf("Skipping plugin %s: %s", path, err)
+				continue
+			}
+
+			spec, err := NewSpec(path)
+			if err != nil {
+				logrus.Warnf("Skipping plugin %s: %s", path, err)
+				continue
+			}
+			specs = append(specs, spec)
+		}
+	}
+	return specs
+}
+
+// Register discovers plugins in the given directories and adds them to the check registry.
+// Plugins with the same name as an already registered check or one of the reserved names,
+// e.g. builtin subcommands, are skipped.
+func Register(dirs []string, reserved []string) []common.CheckSpec {
+	var registered []common.CheckSpec
+	for _, spec := range Discover(dirs) {
+		if _, ok := common.LookupCheck(spec.Name); ok {
+			logrus.Warnf("Skipping plugin %s: a check with the same name is already registered", spec.Name)
+			continue
+		}
+
+		if isReserved(spec.Name, reserved) {
+			logrus.Warnf("Skipping plugin %s: a command with the same name already exists", spec.Name)
+			continue
+		}
+
+		common.RegisterCheck(spec)
+		registered = append(registered, spec)
+	}
+	return registered
+}
+
+// isPlugin returns true for executable regular files which are not manifests.
+func isPlugin(file os.FileInfo) bool {
+	if !file.Mode().IsRegular() || file.Mode().Perm()&0111 == 0 || strings.HasPrefix(file.Name(), ".") {
+		return false
+	}
+
+	ext := filepath.Ext(file.Name())
+	return ext != ".yaml" && ext != ".yml"
+}
+
+// checkPermissions returns an error if the plugin file is world-writable or not owned by root,
+// so it could have been replaced by an unprivileged user.
+func checkPermissions(file os.FileInfo) error {
+	if file.Mode().Perm()&0002 != 0 {
+		return errors.Errorf("file is world-writable (mode %s)", file.Mode().Perm())
+	}
+
+	stat, ok := file.Sys().(*syscall.Stat_t)
+	if !ok {
+		return errors.New("unable to get the file owner")
+	}
+
+	if int(stat.Uid) != pluginOwner {
+		return errors.Errorf("file is owned by uid %d, expected uid %d", stat.Uid, pluginOwner)
+	}
+	return nil
+}
+
+// isReserved returns true if name is in reserved.
+func isReserved(name string, reserved []string) bool {
+	for _, r := range reserved {
+		if name == r {
+			return true
+		}
+	}
+	return false
+}
+
+// NewSpec returns a check spec of the plugin at the given path.
+func NewSpec(path string) (common.CheckSpec, error) {
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	manifest, err := loadManifest(filepath.Join(filepath.Dir(path), base+".yaml"))
+	if err != nil {
+		return common.CheckSpec{}, err
+	}
+
+	if manifest.Name == "" {
+		manifest.Name = base
+	}
+
+	if manifest.Description == "" {
+		manifest.Description = fmt.Sprintf("Run plugin %s", filepath.Base(path))
+	}
+
+	var timeout time.Duration
+	if manifest.Timeout != "" {
+		if timeout, err = time.ParseDuration(manifest.Timeout); err != nil {
+			return common.CheckSpec{}, errors.Wrapf(err, "invalid timeout of plugin %s", path)
+		}
+	}
+
+	params := manifest.Params
+	return common.CheckSpec{
+		Name:          manifest.Name,
+		Description:   manifest.Description,
+		Long:          strings.TrimSpace(fmt.Sprintf("%s\n\nPlugin: %s", manifest.Long, path)),
+		Roles:         manifest.Roles,
+		Tags:          append(manifest.Tags, pluginTag),
+		Requires:      manifest.Requires,
+		Timeout:       timeout,
+		ClusterAccess: manifest.ClusterAccess,
+		Flags: func(flags *pflag.FlagSet) {
+			for _, param := range params {
+				flags.String(param.Name, param.Default, param.Usage)
+			}
+		},
+		New: func(flags *pflag.FlagSet, args []string) (common.DCOSChecker, error) {
+			check := &pluginCheck{
+				Name:   fmt.Sprintf("plugin %s", manifest.Name),
+				Path:   path,
+				Args:   args,
+				Params: make(map[string]string, len(params)),
+
+				remediation: manifest.Remediation,
+			}
+
+			for _, param := range params {
+				value, err := flags.GetString(param.Name)
+				if err != nil {
+					return nil, err
+				}
+				check.Params[param.Name] = value
+			}
+			return check, nil
+		},
+	}, nil
+}
+
+// loadManifest reads a plugin manifest. An empty manifest is returned if the file does not exist.
+func loadManifest(path string) (Manifest, error) {
+	var manifest Manifest
+	body, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return manifest, nil
+	}
+
+	if err != nil {
+		return manifest, errors.Wrapf(err, "unable to read plugin manifest %s", path)
+	}
+
+	if err := yaml.Unmarshal(body, &manifest); err != nil {
+		return manifest, errors.Wrapf(err, "invalid plugin manifest %s", path)
+	}
+	return manifest, nil
+}
+
+// ID returns a unique check identifier.
+func (p *pluginCheck) ID() string {
+	return p.Name
+}
+
+// Run executes the plugin and maps its exit code to a check status.
+func (p *pluginCheck) Run(ctx context.Context, cfg *common.CLIConfigFlags) (string, int, error) {
+	return p.RunDetailed(ctx, cfg).Tuple()
+}
+
+// RunDetailed executes the plugin and returns the remediation from the plugin manifest
+// with a non-OK result.
+func (p *pluginCheck) RunDetailed(ctx context.Context, cfg *common.CLIConfigFlags) common.CheckResult {
+	result := common.NewCheckResult(p.run(ctx, cfg))
+	if result.Status != constants.StatusOK {
+		result.Remediation = p.remediation
+	}
+	return result
+}
+
+// run executes the plugin and maps its exit code to a check status. The plugin is killed
+// when ctx is done.
+func (p *pluginCheck) run(ctx context.Context, cfg *common.CLIConfigFlags) (string, int, error) {
+	cmd := exec.CommandContext(ctx, append([]string{p.Path}, p.Args...)...)
+	cmd.Env = append(os.Environ(), p.env(cfg)...)
+
+	stdout, stderr, code, err := exec.FullOutput(cmd)
+	output := strings.TrimSpace(string(stdout))
+	if ctx.Err() != nil {
+		return output, constants.StatusUnknown, errors.Wrapf(ctx.Err(), "plugin %s did not finish", p.Path)
+	}
+
+	if err != nil {
+		return output, constants.StatusUnknown, errors.Wrapf(err, "unable to execute plugin %s", p.Path)
+	}
+
+	if len(stderr) > 0 {
+		logrus.Debugf("plugin %s stderr: %s", p.Path, stderr)
+	}
+
+	if code < constants.StatusOK || code > constants.StatusUnknown {
+		return output, constants.StatusUnknown, errors.Errorf("plugin %s exited with code %d: %s", p.Path, code,
+			strings.TrimSpace(string(stderr)))
+	}
+	return output, code, nil
+}
+
+// Explain returns the plugin command. The global flags and the check parameters are
+// passed to the plugin in the environment.
+func (p *pluginCheck) Explain(ctx context.Context, cfg *common.CLIConfigFlags) ([]common.Action, error) {
+	return []common.Action{{
+		Kind:        common.ActionExec,
+		Description: fmt.Sprintf("run plugin with %s* environment variables", envPrefix),
+		Command:     append([]string{p.Path}, p.Args...),
+	}}, nil
+}
+
+// env returns the environment variables with the global flags and the check parameters.
+func (p *pluginCheck) env(cfg *common.CLIConfigFlags) []string {
+	if cfg == nil {
+		cfg = &common.CLIConfigFlags{}
+	}
+
+	env := []string{
+		envPrefix + "ROLE=" + cfg.Role,
+		envPrefix + "NODE_IP=" + cfg.NodeIPStr,
+		envPrefix + "DETECT_IP=" + cfg.DetectIP,
+		envPrefix + "FORCE_TLS=" + strconv.FormatBool(cfg.ForceTLS),
+		envPrefix + "IAM_CONFIG=" + cfg.IAMConfig,
+		envPrefix + "CA_CERT=" + cfg.CACert,
+		envPrefix + "VERBOSE=" + strconv.FormatBool(cfg.Verbose),
+	}
+
+	names := make([]string, 0, len(p.Params))
+	for name := range p.Params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		env = append(env, fmt.Sprintf("%sPARAM_%s=%s", envPrefix, envName(name), p.Params[name]))
+	}
+	return env
+}
+
+// envName converts a parameter name to an environment variable name, e.g. max-offset to MAX_OFFSET.
+func envName(name string) string {
+	return strings.ToUpper(strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, name))
+}
@@ -0,0 +1,185 @@
+package plugin
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dcos/dcos-checks/common"
+	"github.com/dcos/dcos-checks/constants"
+)
+
+func writeFile(t *testing.T, path, body string, mode os.FileMode) {
+	if err := ioutil.WriteFile(path, []byte(body), mode); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newPluginDir(t *testing.T) string {
+	// plugins are created by the user running the tests.
+	pluginOwner = os.Getuid()
+
+	dir, err := ioutil.TempDir("", "dcos-checks-plugins")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, filepath.Join(dir, "offset.sh"), `#!/bin/sh
+echo "role $DCOS_CHECKS_ROLE offset $DCOS_CHECKS_PARAM_MAX_OFFSET args $@"
+exit 1
+`, 0755)
+	writeFile(t, filepath.Join(dir, "offset.yaml"), `
+name: clock-offset
+description: Check clock offset
+roles: [master]
+tags: [node]
+requires: [ip]
+timeout: 2s
+params:
+  - name: max-offset
+    default: 100ms
+    usage: maximum offset
+remediation:
+  - hint: Make sure ntpd is running
+    command: systemctl restart ntpd
+`, 0644)
+	writeFile(t, filepath.Join(dir, "crash"), "#!/bin/sh\necho oops >&2\nexit 42\n", 0755)
+	writeFile(t, filepath.Join(dir, "sleep"), "#!/bin/sh\nexec sleep 10\n", 0755)
+	writeFile(t, filepath.Join(dir, "README"), "not a plugin", 0644)
+	return dir
+}
+
+func discover(t *testing.T, dir string) map[string]common.CheckSpec {
+	specs := Discover([]string{dir, filepath.Join(dir, "missing")})
+	byName := make(map[string]common.CheckSpec)
+	for _, spec := range specs {
+		byName[spec.Name] = spec
+	}
+	return byName
+}
+
+func TestDiscover(t *testing.T) {
+	dir := newPluginDir(t)
+	defer os.RemoveAll(dir)
+
+	specs := discover(t, dir)
+	if len(specs) != 3 {
+		t.Fatalf("expect 3 plugins. Got %+v", specs)
+	}
+
+	spec, ok := specs["clock-offset"]
+	if !ok {
+		t.Fatal("expect the manifest name to be used")
+	}
+
+	if spec.Timeout != 2*time.Second || !spec.HasRole("master") || len(spec.Requires) != 1 {
+		t.Fatalf("unexpected spec %+v", spec)
+	}
+
+	task, err := spec.NewTask([]string{"a"}, map[string]interface{}{"max-offset": "1s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !task.HasTag("node") || !task.HasTag("plugin") {
+		t.Fatalf("expect tags node and plugin. Got %v", task.Tags)
+	}
+
+	result := task.Check.(common.DetailedChecker).RunDetailed(context.TODO(), &common.CLIConfigFlags{Role: "master"})
+	output, status, err := result.Tuple()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status != constants.StatusWarning || output != "role master offset 1s args a" {
+		t.Fatalf("unexpected output %q and status %d", output, status)
+	}
+
+	remediation := result.Remediation
+	if len(remediation) != 1 || remediation[0].Command != "systemctl restart ntpd" {
+		t.Fatalf("expect remediation from the manifest. Got %v", remediation)
+	}
+}
+
+func TestDiscoverInvalidManifest(t *testing.T) {
+	dir := newPluginDir(t)
+	defer os.RemoveAll(dir)
+
+	writeFile(t, filepath.Join(dir, "broken"), "#!/bin/sh\nexit 0\n", 0755)
+	writeFile(t, filepath.Join(dir, "broken.yaml"), "timeout: [", 0644)
+
+	specs := discover(t, dir)
+	if _, ok := specs["broken"]; ok || len(specs) != 3 {
+		t.Fatalf("expect the broken plugin to be skipped. Got %+v", specs)
+	}
+}
+
+func TestDiscoverUnsafeFiles(t *testing.T) {
+	dir := newPluginDir(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "writable")
+	writeFile(t, path, "#!/bin/sh\nexit 0\n", 0755)
+	if err := os.Chmod(path, 0777); err != nil {
+		t.Fatal(err)
+	}
+
+	specs := discover(t, dir)
+	if _, ok := specs["writable"]; ok || len(specs) != 3 {
+		t.Fatalf("expect the world-writable plugin to be skipped. Got %+v", specs)
+	}
+
+	pluginOwner = os.Getuid() + 1
+	defer func() { pluginOwner = os.Getuid() }()
+
+	if specs := discover(t, dir); len(specs) != 0 {
+		t.Fatalf("expect plugins not owned by uid %d to be skipped. Got %+v", pluginOwner, specs)
+	}
+}
+
+func TestRegisterReserved(t *testing.T) {
+	dir := newPluginDir(t)
+	defer os.RemoveAll(dir)
+
+	specs := Register([]string{dir}, []string{"clock-offset", "crash", "sleep"})
+	if len(specs) != 0 {
+		t.Fatalf("expect plugins with reserved names to be skipped. Got %+v", specs)
+	}
+
+	if _, ok := common.LookupCheck("crash"); ok {
+		t.Fatal("expect the reserved plugin not to be registered")
+	}
+}
+
+func TestPluginExitCodes(t *testing.T) {
+	dir := newPluginDir(t)
+	defer os.RemoveAll(dir)
+
+	specs := discover(t, dir)
+	task, err := specs["crash"].NewTask(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, status, err := task.Check.Run(context.TODO(), nil)
+	if status != constants.StatusUnknown || err == nil {
+		t.Fatalf("expect unknown status and error for exit code 42. Got %d, %v", status, err)
+	}
+
+	task, err = specs["sleep"].NewTask(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, status, err = task.Check.Run(ctx, nil)
+	if status != constants.StatusUnknown || err == nil || time.Since(start) > 5*time.Second {
+		t.Fatalf("expect the plugin to be killed on timeout. Got %d, %v", status, err)
+	}
+}
@@ -0,0 +1,312 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dcos/dcos-checks/common"
+	"github.com/dcos/dcos-checks/constants"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// errNotFound is returned by Run if a check with the given name is not scheduled.
+var errNotFound = errors.New("check not found")
+
+// TasksFunc returns new tasks for a single run of the checks.
+type TasksFunc func() ([]common.Task, error)
+
+// Server executes checks on an interval, caches the latest result of each check
+// and exposes the results via HTTP API:
+//
+//	GET  /v1/checks            latest results of all checks
+//	GET  /v1/checks/<name>     latest result of a check
+//	POST /v1/checks/<name>/run run a check and return the result
+//	POST /v1/run               run all checks and return the results
+//	GET  /v1/status            overall node status
+//	GET  /metrics              latest results in Prometheus text exposition format
+type Server struct {
+	// ctx is the context of the checks executed on HTTP requests, so a client disconnect
+	// does not cancel a run.
+	ctx context.Context
+
+	cfg      *common.CLIConfigFlags
+	tasks    TasksFunc
+	interval time.Duration
+
+	// runMu serializes the runs, which read and write the check history.
+	runMu sync.Mutex
+
+	mu      sync.RWMutex
+	names   []string
+	results map[string]common.Result
+	lastRun time.Time
+}
+
+// statusResponse is a response of the /v1/status endpoint.
+type statusResponse struct {
+	Status     int    `json:"status"`
+	StatusName string `json:"status_name"`
+	LastRun    string `json:"last_run,omitempty"`
+	Checks     int    `json:"checks"`
+}
+
+// errorResponse is returned if a request cannot be handled.
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// New returns a new instance of Server. The checks requested via HTTP API are executed
+// with ctx.
+func New(ctx context.Context, cfg *common.CLIConfigFlags, tasks TasksFunc, interval time.Duration) *Server {
+	return &Server{
+		ctx:      ctx,
+		cfg:      cfg,
+		tasks:    tasks,
+		interval: interval,
+		results:  make(map[string]common.Result),
+	}
+}
+
+// Start runs all checks immediately and then on every interval until ctx is done.
+func (s *Server) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.RunAll(ctx); err != nil {
+			logrus.Errorf("Unable to run checks: %s", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunAll executes all checks and caches the results.
+func (s *Server) RunAll(ctx context.Context) ([]common.Result, error) {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	tasks, err := s.tasks()
+	if err != nil {
+		return nil, err
+	}
+
+	results := common.ApplyHistory(s.cfg, common.RunChecks(ctx, s.cfg, tasks))
+	s.writeTextfile(results)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.names = make([]string, 0, len(results))
+	s.results = make(map[string]common.Result, len(results))
+	for _, result := range results {
+		s.names = append(s.names, result.Name)
+		s.results[result.Name] = result
+	}
+	s.lastRun = time.Now()
+
+	return results, nil
+}
+
+// Run executes a single check by name and caches the result.
+func (s *Server) Run(ctx context.Context, name string) (common.Result, error) {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	tasks, err := s.tasks()
+	if err != nil {
+		return common.Result{}, err
+	}
+
+	for _, task := range tasks {
+		if task.DisplayName() != name {
+			continue
+		}
+
+		result := common.ApplyHistory(s.cfg, common.RunChecks(ctx, s.cfg, []common.Task{task}))[0]
+
+		s.mu.Lock()
+		if _, ok := s.results[name]; !ok {
+			s.names = append(s.names, name)
+		}
+		s.results[name] = result
+		s.mu.Unlock()
+
+		// the textfile contains the metrics of all checks.
+		s.writeTextfile(s.Results())
+		return result, nil
+	}
+
+	return common.Result{}, errNotFound
+}
+
+// writeTextfile writes the metrics of the results to the Prometheus textfile if set.
+func (s *Server) writeTextfile(results []common.Result) {
+	if s.cfg.PrometheusTextfile == "" {
+		return
+	}
+
+	if err := common.WritePrometheusTextfile(s.cfg.PrometheusTextfile, s.cfg, results); err != nil {
+		logrus.Errorf("Unable to write Prometheus textfile: %s", err)
+	}
+}
+
+// Results returns the latest results of all checks.
+func (s *Server) Results() []common.Result {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	results := make([]common.Result, 0, len(s.names))
+	for _, name := range s.names {
+		results = append(results, s.results[name])
+	}
+	return results
+}
+
+// Result returns the latest result of a check.
+func (s *Server) Result(name string) (common.Result, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result, ok := s.results[name]
+	return result, ok
+}
+
+// Handler returns an http.Handler serving the HTTP API.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/checks", s.handleChecks)
+	mux.HandleFunc("/v1/checks/", s.handleCheck)
+	mux.HandleFunc("/v1/run", s.handleRun)
+	mux.HandleFunc("/v1/status", s.handleStatus)
+	mux.HandleFunc("/metrics", s.handleMetrics)
+	return mux
+}
+
+func (s *Server) handleChecks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errors.Errorf("method %s not allowed", r.Method))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, common.NewResultDocuments(s.cfg, s.Results()))
+}
+
+func (s *Server) handleCheck(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/v1/checks/")
+	if strings.HasSuffix(name, "/run") {
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, errors.Errorf("method %s not allowed", r.Method))
+			return
+		}
+
+		name = strings.TrimSuffix(name, "/run")
+		result, err := s.Run(s.ctx, name)
+		if err == errNotFound {
+			writeError(w, http.StatusNotFound, errors.Errorf("check %s not found", name))
+			return
+		}
+
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, common.NewResultDocuments(s.cfg, []common.Result{result})[0])
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errors.Errorf("method %s not allowed", r.Method))
+		return
+	}
+
+	result, ok := s.Result(name)
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.Errorf("no result for check %s", name))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, common.NewResultDocuments(s.cfg, []common.Result{result})[0])
+}
+
+func (s *Server) handleRun(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errors.Errorf("method %s not allowed", r.Method))
+		return
+	}
+
+	results, err := s.RunAll(s.ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, common.NewResultDocuments(s.cfg, results))
+}
+
+// handleStatus returns the overall node status. The response code is 200 if the worst
+// status is OK or warning and 503 otherwise, so the endpoint can be used by load balancers.
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errors.Errorf("method %s not allowed", r.Method))
+		return
+	}
+
+	results := s.Results()
+
+	s.mu.RLock()
+	lastRun := s.lastRun
+	s.mu.RUnlock()
+
+	status := common.WorstStatus(results)
+	if len(results) == 0 {
+		status = constants.StatusUnknown
+	}
+
+	response := statusResponse{
+		Status:     status,
+		StatusName: common.StatusName(status),
+		Checks:     len(results),
+	}
+
+	if !lastRun.IsZero() {
+		response.LastRun = lastRun.Format(time.RFC3339Nano)
+	}
+
+	code := http.StatusOK
+	if status != constants.StatusOK && status != constants.StatusWarning {
+		code = http.StatusServiceUnavailable
+	}
+
+	writeJSON(w, code, response)
+}
+
+// handleMetrics returns the latest results in Prometheus text exposition format.
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	if err := common.WritePrometheusMetrics(w, s.cfg, s.Results()); err != nil {
+		logrus.Errorf("Unable to write metrics: %s", err)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logrus.Errorf("Unable to encode response: %s", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, errorResponse{Error: err.Error()})
+}
@@ -0,0 +1,224 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dcos/dcos-checks/common"
+	"github.com/dcos/dcos-checks/constants"
+)
+
+type fakeCheck struct {
+	output string
+	status int
+}
+
+func (f fakeCheck) ID() string {
+	return "fakeCheck"
+}
+
+func (f fakeCheck) Run(context.Context, *common.CLIConfigFlags) (string, int, error) {
+	return f.output, f.status, nil
+}
+
+func newTestServer(statuses map[string]int) *Server {
+	tasks := func() ([]common.Task, error) {
+		var tasks []common.Task
+		for _, name := range []string{"first", "second"} {
+			tasks = append(tasks, common.Task{
+				Name:  name,
+				Check: fakeCheck{output: name + " output", status: statuses[name]},
+			})
+		}
+		return tasks, nil
+	}
+
+	return New(context.Background(), &common.CLIConfigFlags{NodeIPStr: "127.0.0.1"}, tasks, time.Minute)
+}
+
+func doRequest(t *testing.T, s *Server, method, path string, v interface{}) int {
+	req := httptest.NewRequest(method, path, nil)
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, req)
+
+	if v != nil {
+		if err := json.NewDecoder(w.Body).Decode(v); err != nil {
+			t.Fatalf("unable to decode response of %s %s: %s", method, path, err)
+		}
+	}
+	return w.Code
+}
+
+func TestServerStatus(t *testing.T) {
+	s := newTestServer(map[string]int{"second": constants.StatusFailure})
+
+	var status statusResponse
+	if code := doRequest(t, s, http.MethodGet, "/v1/status", &status); code != http.StatusServiceUnavailable {
+		t.Fatalf("expect 503 before the first run. Got %d", code)
+	}
+
+	if _, err := s.RunAll(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+
+	if code := doRequest(t, s, http.MethodGet, "/v1/status", &status); code != http.StatusServiceUnavailable {
+		t.Fatalf("expect 503 for a failed check. Got %d", code)
+	}
+
+	if status.Status != constants.StatusFailure || status.Checks != 2 || status.LastRun == "" {
+		t.Fatalf("unexpected status %+v", status)
+	}
+}
+
+func TestServerChecks(t *testing.T) {
+	s := newTestServer(map[string]int{"second": constants.StatusWarning})
+	if _, err := s.RunAll(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+
+	var documents []common.ResultDocument
+	if code := doRequest(t, s, http.MethodGet, "/v1/checks", &documents); code != http.StatusOK {
+		t.Fatalf("expect 200. Got %d", code)
+	}
+
+	if len(documents) != 2 || documents[0].Name != "first" || documents[1].StatusName != "WARNING" {
+		t.Fatalf("unexpected documents %+v", documents)
+	}
+
+	var doc common.ResultDocument
+	if code := doRequest(t, s, http.MethodGet, "/v1/checks/first", &doc); code != http.StatusOK {
+		t.Fatalf("expect 200. Got %d", code)
+	}
+
+	if doc.Output != "first output" || doc.NodeIP != "127.0.0.1" {
+		t.Fatalf("unexpected document %+v", doc)
+	}
+
+	if code := doRequest(t, s, http.MethodGet, "/v1/checks/unknown", nil); code != http.StatusNotFound {
+		t.Fatalf("expect 404 for unknown check. Got %d", code)
+	}
+
+	var status statusResponse
+	if code := doRequest(t, s, http.MethodGet, "/v1/status", &status); code != http.StatusOK {
+		t.Fatalf("expect 200 for a warning. Got %d", code)
+	}
+}
+
+func TestServerRunCheck(t *testing.T) {
+	s := newTestServer(nil)
+
+	var doc common.ResultDocument
+	if code := doRequest(t, s, http.MethodPost, "/v1/checks/second/run", &doc); code != http.StatusOK {
+		t.Fatalf("expect 200. Got %d", code)
+	}
+
+	if doc.Name != "second" || doc.Status != constants.StatusOK {
+		t.Fatalf("unexpected document %+v", doc)
+	}
+
+	if _, ok := s.Result("second"); !ok {
+		t.Fatal("expect the result to be cached")
+	}
+
+	if code := doRequest(t, s, http.MethodGet, "/v1/checks/second/run", nil); code != http.StatusMethodNotAllowed {
+		t.Fatalf("expect 405 for GET. Got %d", code)
+	}
+
+	if code := doRequest(t, s, http.MethodPost, "/v1/checks/unknown/run", nil); code != http.StatusNotFound {
+		t.Fatalf("expect 404 for unknown check. Got %d", code)
+	}
+
+	var documents []common.ResultDocument
+	if code := doRequest(t, s, http.MethodPost, "/v1/run", &documents); code != http.StatusOK || len(documents) != 2 {
+		t.Fatalf("expect 200 and 2 results. Got %d and %d", code, len(documents))
+	}
+}
+
+func TestServerMetrics(t *testing.T) {
+	s := newTestServer(map[string]int{"second": constants.StatusFailure})
+	if _, err := s.RunAll(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, req)
+
+	expected := `dcos_check_status{check="second",role=""} 2`
+	if !strings.Contains(w.Body.String(), expected) {
+		t.Fatalf("expect metrics to contain %s. Got %s", expected, w.Body.String())
+	}
+}
+
+// contextCheck fails if its context is done.
+type contextCheck struct{}
+
+func (contextCheck) ID() string {
+	return "contextCheck"
+}
+
+func (contextCheck) Run(ctx context.Context, cfg *common.CLIConfigFlags) (string, int, error) {
+	if err := ctx.Err(); err != nil {
+		return "", constants.StatusUnknown, err
+	}
+	return "", constants.StatusOK, nil
+}
+
+func TestServerRunClientDisconnect(t *testing.T) {
+	tasks := func() ([]common.Task, error) {
+		return []common.Task{{Name: "context", Check: contextCheck{}}}, nil
+	}
+	s := New(context.Background(), &common.CLIConfigFlags{NodeIPStr: "127.0.0.1"}, tasks, time.Minute)
+
+	// a client which disconnected must not cancel the checks.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/checks/context/run", nil).WithContext(ctx)
+	s.Handler().ServeHTTP(httptest.NewRecorder(), req)
+
+	if result, ok := s.Result("context"); !ok || result.Status != constants.StatusOK {
+		t.Fatalf("expect the check to run with the server context. Got %+v", result)
+	}
+}
+
+func TestServerRunCheckTextfile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dcos-checks-server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := newTestServer(map[string]int{"second": constants.StatusFailure})
+	s.cfg.PrometheusTextfile = filepath.Join(dir, "checks.prom")
+	if _, err := s.RunAll(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+
+	// the textfile is refreshed by a single check run and keeps the metrics of the other checks.
+	s.tasks = func() ([]common.Task, error) {
+		return []common.Task{{Name: "second", Check: fakeCheck{status: constants.StatusOK}}}, nil
+	}
+	if _, err := s.Run(context.TODO(), "second"); err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := ioutil.ReadFile(s.cfg.PrometheusTextfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{`dcos_check_status{check="first",role=""} 0`, `dcos_check_status{check="second",role=""} 0`} {
+		if !strings.Contains(string(body), expected) {
+			t.Fatalf("expect textfile to contain %s. Got %s", expected, body)
+		}
+	}
+}